@@ -0,0 +1,101 @@
+package evon
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrNodeNotFound = errors.New("node not found")
+	ErrNoValue      = errors.New("node has no value")
+)
+
+// Get returns value of node by its path converted to T.
+// Conversion follows the same rules as struct unmarshalling
+// e.g.
+//
+//	port, err := Get[uint16](storage, "DATA-SOURCES_POSTGRES_PORT")
+//	timeout, err := Get[time.Duration](storage, "APP-INFO_STARTUP-DURATION")
+//	ports, err := Get[[]int](storage, "ENVIRONMENT_AVAILABLE-PORTS")
+func Get[T any](storage NodeStorage, path string) (T, error) {
+	var out T
+
+	err := getValue(storage, path, reflect.ValueOf(&out).Elem())
+	if err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
+
+// GetOr returns value of node by its path converted to T.
+// If node doesn't exist, has no value or can't be converted returns def
+func GetOr[T any](storage NodeStorage, path string, def T) T {
+	out, err := Get[T](storage, path)
+	if err != nil {
+		return def
+	}
+
+	return out
+}
+
+// MustGet returns value of node by its path converted to T.
+// Panics if node doesn't exist, has no value or can't be converted
+func MustGet[T any](storage NodeStorage, path string) T {
+	out, err := Get[T](storage, path)
+	if err != nil {
+		panic(err)
+	}
+
+	return out
+}
+
+func getValue(storage NodeStorage, path string, dst reflect.Value) error {
+	root, ok := storage[path]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, path)
+	}
+
+	plan := mappingPlanOf(dst.Type())
+	prefix := strings.ToUpper(path)
+
+	// intermediate node e.g. "DB" of "DB_HOST" can only be read
+	// into structs, maps and slices
+	if _, ok := plan.entries[""]; ok && root.Value == nil && !isContainerType(dst.Type()) {
+		return fmt.Errorf("%w: %s", ErrNoValue, path)
+	}
+
+	var set func(n *Node) error
+	set = func(n *Node) error {
+		name, ok := relativeName(prefix, strings.ToUpper(n.Name))
+		if ok {
+			_, err := plan.set(dst, name, n)
+			if err != nil {
+				return fmt.Errorf("error getting value of %s: %w", n.Name, err)
+			}
+		}
+
+		for _, inner := range n.InnerNodes {
+			err := set(inner)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return set(root)
+}
+
+// isContainerType reports if tp is slice or map
+// that can be filled from inner nodes
+func isContainerType(tp reflect.Type) bool {
+	for tp.Kind() == reflect.Pointer {
+		tp = tp.Elem()
+	}
+
+	return tp.Kind() == reflect.Slice || tp.Kind() == reflect.Map
+}
@@ -0,0 +1,160 @@
+package evon
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	t.Parallel()
+
+	ns := ParseToNodes(matreshkaDotEnv)
+
+	t.Run("string", func(t *testing.T) {
+		t.Parallel()
+
+		actual, err := Get[string](ns, "DATA-SOURCES_POSTGRES_HOST")
+		require.NoError(t, err)
+		require.Equal(t, "localhost", actual)
+	})
+
+	t.Run("uint", func(t *testing.T) {
+		t.Parallel()
+
+		actual, err := Get[uint16](ns, "DATA-SOURCES_POSTGRES_PORT")
+		require.NoError(t, err)
+		require.Equal(t, uint16(5433), actual)
+	})
+
+	t.Run("duration", func(t *testing.T) {
+		t.Parallel()
+
+		actual, err := Get[time.Duration](ns, "APP-INFO_STARTUP-DURATION")
+		require.NoError(t, err)
+		require.Equal(t, time.Second*10, actual)
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		t.Parallel()
+
+		type AppInfo struct {
+			Name            string
+			Version         string
+			StartupDuration time.Duration
+		}
+
+		actual, err := Get[AppInfo](ns, "APP-INFO")
+		require.NoError(t, err)
+		require.Equal(t, AppInfo{
+			Name:            "kv_Test_PatchConfig_Test_PatchConfigDataSources",
+			Version:         "v0.0.1",
+			StartupDuration: time.Second * 10,
+		}, actual)
+	})
+
+	t.Run("not_found", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Get[string](ns, "NOT_EXISTING")
+		require.ErrorIs(t, err, ErrNodeNotFound)
+	})
+
+	t.Run("no_value", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := Get[string](ns, "DATA-SOURCES_POSTGRES")
+		require.ErrorIs(t, err, ErrNoValue)
+	})
+}
+
+func TestGetConversions(t *testing.T) {
+	t.Parallel()
+
+	ns := ParseToNodes([]byte(`TIME=2024-10-12 10:11:12
+ADDR=127.0.0.1
+PORTS=80,443,8080
+RATIO=0.75
+`))
+
+	tm, err := Get[time.Time](ns, "TIME")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 10, 12, 10, 11, 12, 0, time.UTC), tm)
+
+	addr, err := Get[netip.Addr](ns, "ADDR")
+	require.NoError(t, err)
+	require.Equal(t, netip.MustParseAddr("127.0.0.1"), addr)
+
+	ports, err := Get[[]int](ns, "PORTS")
+	require.NoError(t, err)
+	require.Equal(t, []int{80, 443, 8080}, ports)
+
+	ratio, err := Get[float64](ns, "RATIO")
+	require.NoError(t, err)
+	require.Equal(t, 0.75, ratio)
+}
+
+func TestGetOr(t *testing.T) {
+	t.Parallel()
+
+	ns := ParseToNodes(matreshkaDotEnv)
+
+	require.Equal(t, 2, GetOr(ns, "DATA-SOURCES_REDIS_DB", 0))
+	require.Equal(t, 10, GetOr(ns, "NOT_EXISTING", 10))
+	require.Equal(t, netip.Addr{}, GetOr(ns, "DATA-SOURCES_REDIS_HOST", netip.Addr{}))
+
+	// intermediate nodes have no value
+	require.Equal(t, 5, GetOr(ns, "DATA-SOURCES_REDIS", 5))
+	require.Equal(t, "def", GetOr(ns, "DATA-SOURCES", "def"))
+	require.Equal(t, time.Time{}.Add(time.Hour), GetOr(ns, "APP-INFO", time.Time{}.Add(time.Hour)))
+
+	require.Panics(t, func() {
+		MustGet[string](ns, "NOT_EXISTING")
+	})
+}
+
+func TestGetMarshalled(t *testing.T) {
+	t.Parallel()
+
+	n, err := MarshalEnv(NewTestObject())
+	require.NoError(t, err)
+
+	ns := NodesToStorage(n)
+
+	actual, err := Get[int64](ns, "ROOT-INT-VALUE")
+	require.NoError(t, err)
+	require.Equal(t, int64(3), actual)
+
+	str, err := Get[string](ns, "CHILD-OBJ-VALUE_BOOL-VALUE")
+	require.NoError(t, err)
+	require.Equal(t, "true", str)
+}
+
+func TestGetKeepsStorage(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`SERVERS_[0]_NAME=rest
+SERVERS_[0]_PORT=8080
+SERVERS_[1]_NAME=grpc
+SERVERS_[1]_PORT=50051
+`)
+	ns := ParseToNodes(src)
+
+	type server struct {
+		Name string `env:"NAME"`
+		Port int    `env:"PORT"`
+	}
+
+	expected := []server{{Name: "rest", Port: 8080}, {Name: "grpc", Port: 50051}}
+
+	for range 2 {
+		actual, err := Get[[]server](ns, "SERVERS")
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	}
+
+	require.Equal(t, ParseToNodes(src), ns)
+	require.Equal(t, string(src), string(Marshal(ns[""].InnerNodes)))
+}
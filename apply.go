@@ -0,0 +1,159 @@
+package evon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrApplyConflict = errors.New("apply conflict")
+)
+
+// ApplyConflict describes node in target
+// which current value differs from the one recorded in diff
+type ApplyConflict struct {
+	Path     string
+	Expected any
+	Actual   any
+}
+
+type ApplyConflictError struct {
+	Conflicts []ApplyConflict
+}
+
+func (e *ApplyConflictError) Error() string {
+	paths := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		paths = append(paths, c.Path)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrApplyConflict, strings.Join(paths, ", "))
+}
+
+func (e *ApplyConflictError) Unwrap() error {
+	return ErrApplyConflict
+}
+
+// Apply replays diff onto target.
+// Diff is applied only if there are no conflicts, otherwise *ApplyConflictError is returned
+// and target is left untouched.
+// Conflict appears when target's current value differs from the one recorded in diff
+// e.g.
+//
+//	Added   - target already has node with another value
+//	Changed - target has no such node or its value differs from NodeChange.Old
+//	Removed - target's node value differs from the removed one
+func Apply(target *Node, d NodeDiff) error {
+	ns := NodesToStorage(target)
+
+	effective, err := planApply(ns, d)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range effective.Added {
+		ns.AddNode(&Node{
+			Name:  n.Name,
+			Value: n.Value,
+		})
+	}
+
+	for _, c := range effective.Changed {
+		ns[c.Path].Value = c.New
+	}
+
+	for _, n := range effective.Removed {
+		// children of node aren't a part of removed value
+		if len(n.InnerNodes) != 0 {
+			n.Value = nil
+			continue
+		}
+
+		ns.RemoveNode(n.Name)
+	}
+
+	return nil
+}
+
+// ApplyDryRun checks diff against target without changing it.
+// Returns diff with changes that Apply would actually make
+// or *ApplyConflictError when diff can't be applied
+func ApplyDryRun(target *Node, d NodeDiff) (NodeDiff, error) {
+	return planApply(NodesToStorage(target), d)
+}
+
+func planApply(ns NodeStorage, d NodeDiff) (NodeDiff, error) {
+	var effective NodeDiff
+	var conflicts []ApplyConflict
+
+	for _, n := range d.Added {
+		existing, ok := ns[n.Name]
+		if !ok || existing.Value == nil {
+			effective.Added = append(effective.Added, n)
+			continue
+		}
+
+		if !valuesEqual(existing.Value, n.Value) {
+			conflicts = append(conflicts, ApplyConflict{
+				Path:     n.Name,
+				Expected: nil,
+				Actual:   existing.Value,
+			})
+		}
+	}
+
+	for _, c := range d.Changed {
+		existing, ok := ns[c.Path]
+		if !ok || existing.Value == nil {
+			conflicts = append(conflicts, ApplyConflict{
+				Path:     c.Path,
+				Expected: c.Old,
+			})
+			continue
+		}
+
+		if valuesEqual(existing.Value, c.New) {
+			continue
+		}
+
+		if !valuesEqual(existing.Value, c.Old) {
+			conflicts = append(conflicts, ApplyConflict{
+				Path:     c.Path,
+				Expected: c.Old,
+				Actual:   existing.Value,
+			})
+			continue
+		}
+
+		effective.Changed = append(effective.Changed, NodeChange{
+			Path: c.Path,
+			Old:  existing.Value,
+			New:  c.New,
+		})
+	}
+
+	for _, n := range d.Removed {
+		existing, ok := ns[n.Name]
+		if !ok || existing.Value == nil {
+			continue
+		}
+
+		if !valuesEqual(existing.Value, n.Value) {
+			conflicts = append(conflicts, ApplyConflict{
+				Path:     n.Name,
+				Expected: n.Value,
+				Actual:   existing.Value,
+			})
+			continue
+		}
+
+		effective.Removed = append(effective.Removed, existing)
+	}
+
+	if len(conflicts) != 0 {
+		return NodeDiff{}, &ApplyConflictError{Conflicts: conflicts}
+	}
+
+	return effective, nil
+}
@@ -0,0 +1,133 @@
+package evon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Apply(t *testing.T) {
+	t.Parallel()
+
+	staging := ParseToNodes([]byte(`DB_HOST=staging
+DB_PORT=5432
+CACHE_TTL=10s
+`))[""]
+	stagingNew := ParseToNodes([]byte(`DB_HOST=staging
+DB_PORT=6432
+DB_NAME=app
+`))[""]
+
+	d := Diff(staging, stagingNew)
+
+	prod := ParseToNodes([]byte(`DB_HOST=prod
+DB_PORT=5432
+CACHE_TTL=10s
+`))[""]
+
+	err := Apply(prod, d)
+	require.NoError(t, err)
+
+	require.Equal(t, `DB_HOST=prod
+DB_PORT=6432
+DB_NAME=app
+`, string(Marshal(prod.InnerNodes)))
+}
+
+func Test_ApplyRemoveValueOfNodeWithChildren(t *testing.T) {
+	t.Parallel()
+
+	target := ParseToNodes([]byte("A=1\nA_B=2\n"))[""]
+
+	err := Apply(target, NodeDiff{
+		Removed: []*Node{{Name: "A", Value: "1"}},
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "A_B=2\n", string(Marshal(target.InnerNodes)))
+	require.Nil(t, NodesToStorage(target)["A"].Value)
+
+	err = Apply(target, NodeDiff{
+		Removed: []*Node{{Name: "A_B", Value: "2"}},
+	})
+	require.NoError(t, err)
+
+	require.Empty(t, target.InnerNodes)
+}
+
+func Test_ApplyConflict(t *testing.T) {
+	t.Parallel()
+
+	d := NodeDiff{
+		Added: []*Node{
+			{Name: "DB_NAME", Value: "app"},
+		},
+		Changed: []NodeChange{
+			{Path: "DB_PORT", Old: "5432", New: "6432"},
+			{Path: "DB_USER", Old: "admin", New: "app"},
+		},
+		Removed: []*Node{
+			{Name: "DB_HOST", Value: "localhost"},
+		},
+	}
+
+	target := ParseToNodes([]byte(`DB_HOST=prod
+DB_PORT=7432
+DB_NAME=other
+`))[""]
+
+	err := Apply(target, d)
+
+	var conflictErr *ApplyConflictError
+	require.ErrorAs(t, err, &conflictErr)
+	require.ErrorIs(t, err, ErrApplyConflict)
+	require.Equal(t, []ApplyConflict{
+		{Path: "DB_NAME", Actual: "other"},
+		{Path: "DB_PORT", Expected: "5432", Actual: "7432"},
+		{Path: "DB_USER", Expected: "admin"},
+		{Path: "DB_HOST", Expected: "localhost", Actual: "prod"},
+	}, conflictErr.Conflicts)
+
+	require.Equal(t, `DB_HOST=prod
+DB_PORT=7432
+DB_NAME=other
+`, string(Marshal(target.InnerNodes)))
+}
+
+func Test_ApplyDryRun(t *testing.T) {
+	t.Parallel()
+
+	d := NodeDiff{
+		Added: []*Node{
+			{Name: "DB_NAME", Value: "app"},
+		},
+		Changed: []NodeChange{
+			{Path: "DB_PORT", Old: "5432", New: uint64(6432)},
+		},
+		Removed: []*Node{
+			{Name: "DB_HOST", Value: "localhost"},
+		},
+	}
+
+	target := ParseToNodes([]byte(`DB_PORT=6432
+DB_NAME=app
+`))[""]
+
+	effective, err := ApplyDryRun(target, d)
+	require.NoError(t, err)
+	require.True(t, effective.IsEmpty())
+
+	target = ParseToNodes([]byte(`DB_PORT=5432
+DB_HOST=localhost
+`))[""]
+
+	effective, err = ApplyDryRun(target, d)
+	require.NoError(t, err)
+	require.Len(t, effective.Added, 1)
+	require.Len(t, effective.Changed, 1)
+	require.Len(t, effective.Removed, 1)
+
+	require.Equal(t, `DB_PORT=5432
+DB_HOST=localhost
+`, string(Marshal(target.InnerNodes)))
+}
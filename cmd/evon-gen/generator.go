@@ -0,0 +1,763 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.redsock.ru/evon/internal/structtag"
+)
+
+const (
+	evonPkg    = "go.redsock.ru/evon"
+	annotation = "//evon:generate"
+
+	// helpersMarker is a helper function that is looked up
+	// to not declare helpers twice in one package
+	helpersMarker = "evonWalkNodes"
+)
+
+var ErrUnsupportedType = errors.New("unsupported type")
+
+// helpersSource is copied into generated file,
+// so generated code uses only exported API of evon
+//
+//go:embed internal/helpers/helpers.go
+var helpersSource []byte
+
+// Generate returns source of MarshalEnv and UnmarshalEnv methods
+// for struct types of package in dir.
+// If typeNames is empty, structs annotated with "//evon:generate" are used.
+// File named output is excluded from parsing
+func Generate(dir, output string, typeNames []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	files, err := parseDir(fset, dir, output)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(typeNames) == 0 {
+		typeNames = annotatedTypes(files)
+	}
+
+	if len(typeNames) == 0 {
+		return nil, fmt.Errorf("no types to generate: use -type flag or %s comment", annotation)
+	}
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+	}
+
+	pkg, err := conf.Check(files[0].Name.Name, fset, files, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error type checking package: %w", err)
+	}
+
+	g := &generator{
+		pkg:     pkg,
+		imports: map[string]string{},
+	}
+
+	for _, name := range typeNames {
+		err = g.generateType(name)
+		if err != nil {
+			return nil, fmt.Errorf("error generating %s: %w", name, err)
+		}
+	}
+
+	return g.source()
+}
+
+func parseDir(fset *token.FileSet, dir, output string) ([]*ast.File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading package dir: %w", err)
+	}
+
+	var files []*ast.File
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() ||
+			!strings.HasSuffix(name, ".go") ||
+			strings.HasSuffix(name, "_test.go") ||
+			name == output {
+			continue
+		}
+
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ParseComments)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %w", name, err)
+		}
+
+		files = append(files, f)
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no go files in %s", dir)
+	}
+
+	return files, nil
+}
+
+func annotatedTypes(files []*ast.File) []string {
+	var out []string
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				if hasAnnotation(gd.Doc) || hasAnnotation(ts.Doc) {
+					out = append(out, ts.Name.Name)
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+func hasAnnotation(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == annotation {
+			return true
+		}
+	}
+
+	return false
+}
+
+type generator struct {
+	pkg     *types.Package
+	buf     bytes.Buffer
+	imports map[string]string
+	vars    int
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+func (g *generator) source() ([]byte, error) {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by evon-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", g.pkg.Name())
+
+	g.imports[evonPkg] = "evon"
+
+	var helpers []byte
+	if g.pkg.Scope().Lookup(helpersMarker) == nil {
+		var err error
+		helpers, err = g.helpers()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var std, other []string
+	for path := range g.imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			other = append(other, path)
+		} else {
+			std = append(std, path)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	out.WriteString("import (\n")
+	for _, path := range std {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString("\n")
+	for _, path := range other {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString(")\n\n")
+	out.Write(g.buf.Bytes())
+	out.Write(helpers)
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("error formatting generated code: %w", err)
+	}
+
+	return src, nil
+}
+
+// helpers returns declarations of helpersSource
+// and adds its imports into generated file
+func (g *generator) helpers() ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "helpers.go", helpersSource, parser.ImportsOnly)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing helpers: %w", err)
+	}
+
+	for _, imp := range f.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing helpers: %w", err)
+		}
+
+		g.imports[path] = filepath.Base(path)
+	}
+
+	end := f.Decls[len(f.Decls)-1].End()
+	return helpersSource[fset.Position(end).Offset:], nil
+}
+
+func (g *generator) qualifier(p *types.Package) string {
+	if p == g.pkg {
+		return ""
+	}
+
+	g.imports[p.Path()] = p.Name()
+	return p.Name()
+}
+
+func (g *generator) typeName(t types.Type) string {
+	return types.TypeString(t, g.qualifier)
+}
+
+func (g *generator) newVar(prefix string) string {
+	g.vars++
+	return fmt.Sprintf("%s%d", prefix, g.vars)
+}
+
+func (g *generator) generateType(name string) error {
+	obj := g.pkg.Scope().Lookup(name)
+	if obj == nil {
+		return fmt.Errorf("type %s not found", name)
+	}
+
+	st, ok := obj.Type().Underlying().(*types.Struct)
+	if !ok {
+		return fmt.Errorf("%s is not a struct", name)
+	}
+
+	err := g.generateMarshal(name, st)
+	if err != nil {
+		return err
+	}
+
+	return g.generateUnmarshal(name, st)
+}
+
+type field struct {
+	name      string
+	goName    string
+	tp        types.Type
+	omitempty bool
+	required  bool
+	secret    bool
+}
+
+// structFields returns fields with env names
+// following the same tag rules as reflective evon
+func structFields(st *types.Struct) ([]field, error) {
+	out := make([]field, 0, st.NumFields())
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		tag := structtag.Parse(structtag.Lookup(reflect.StructTag(st.Tag(i))))
+		if tag.Skip {
+			continue
+		}
+
+		if !f.Exported() {
+			return nil, fmt.Errorf("unexported field %s must be skipped with `evon:\"-\"`", f.Name())
+		}
+
+		out = append(out, field{
+			name:      strings.ToUpper(tag.FieldName(f.Name())),
+			goName:    f.Name(),
+			tp:        f.Type(),
+			omitempty: tag.Omitempty,
+			required:  tag.Required,
+			secret:    tag.Secret,
+		})
+	}
+
+	return out, nil
+}
+
+func (g *generator) generateMarshal(name string, st *types.Struct) error {
+	g.vars = 0
+	g.printf("// MarshalEnv returns nodes of %s's fields under prefix\n", name)
+	g.printf("func (v *%s) MarshalEnv(prefix string) ([]*evon.Node, error) {\n", name)
+	g.printf("prefix = strings.ToUpper(prefix)\n")
+	g.printf("if prefix != \"\" {\nprefix += evon.ObjectSplitter\n}\n\n")
+	g.printf("out := make([]*evon.Node, 0, %d)\n", st.NumFields())
+	g.imports["strings"] = "strings"
+
+	err := g.marshalFields("out", "v", st, "prefix", "")
+	if err != nil {
+		return err
+	}
+
+	g.printf("return out, nil\n}\n\n")
+	return nil
+}
+
+// marshalFields appends nodes of struct fields into out.
+// Node names are built as base expression + constant suffix
+func (g *generator) marshalFields(out, recv string, st *types.Struct, base, suffix string) error {
+	fields, err := structFields(st)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		err = g.marshalValue(out, recv+"."+f.goName, f.tp, base, suffix+f.name, f.omitempty, f.secret)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.goName, err)
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) marshalValue(out, access string, t types.Type, base, name string, omitempty, secret bool) error {
+	nameExpr := fmt.Sprintf("%s + %q", base, name)
+	// secretExpr is appended to literals of nodes
+	secretExpr := ""
+	if secret {
+		secretExpr = ", Secret: true"
+	}
+
+	switch u := t.Underlying().(type) {
+	case *types.Pointer:
+		if _, ok := u.Elem().Underlying().(*types.Pointer); ok {
+			return fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+		}
+
+		g.printf("if %s != nil {\n", access)
+		err := g.marshalValue(out, deref(access, u.Elem()), u.Elem(), base, name, false, secret)
+		if err != nil {
+			return err
+		}
+		g.printf("}\n")
+
+	case *types.Basic:
+		zero, ok := zeroValue(u)
+		if !ok {
+			return nil
+		}
+
+		if omitempty {
+			g.printf("if %s != %s {\n", access, zero)
+		}
+		g.printf("%s = append(%s, &evon.Node{Name: %s, Value: %s%s})\n", out, out, nameExpr, access, secretExpr)
+		if omitempty {
+			g.printf("}\n")
+		}
+
+	case *types.Struct:
+		if omitempty {
+			if !types.Comparable(t) {
+				return fmt.Errorf("%w: omitempty of not comparable %s", ErrUnsupportedType, t)
+			}
+			g.printf("if %s != (%s{}) {\n", access, g.typeName(t))
+		}
+
+		if isTime(t) {
+			g.printf("%s = append(%s, &evon.Node{Name: %s, Value: evonFormatTime(%s)%s})\n", out, out, nameExpr, access, secretExpr)
+		} else {
+			n := g.newVar("n")
+			g.printf("%s := &evon.Node{Name: %s%s}\n", n, nameExpr, secretExpr)
+			err := g.marshalFields(n+".InnerNodes", access, u, base, name+"_")
+			if err != nil {
+				return err
+			}
+			g.printf("%s = append(%s, %s)\n", out, out, n)
+		}
+
+		if omitempty {
+			g.printf("}\n")
+		}
+
+	case *types.Slice:
+		g.printf("if len(%s) != 0 {\n", access)
+
+		switch elem := u.Elem().Underlying().(type) {
+		case *types.Basic:
+			if _, ok := zeroValue(elem); !ok {
+				return fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+			}
+			g.printf("%s = append(%s, &evon.Node{Name: %s, Value: evonFormatSlice(%s)%s})\n", out, out, nameExpr, access, secretExpr)
+
+		case *types.Struct:
+			if isTime(u.Elem()) || hasMethod(u.Elem(), "MarshalEnv") {
+				return fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+			}
+
+			n := g.newVar("n")
+			i := g.newVar("i")
+			e := g.newVar("e")
+			p := g.newVar("p")
+			g.printf("%s := &evon.Node{Name: %s%s}\n", n, nameExpr, secretExpr)
+			g.printf("for %s := range %s {\n", i, access)
+			g.printf("%s := &evon.Node{Name: %s.Name + \"_[\" + strconv.Itoa(%s) + \"]\"}\n", e, n, i)
+			g.printf("%s := %s.Name + evon.ObjectSplitter\n", p, e)
+			g.imports["strconv"] = "strconv"
+
+			err := g.marshalFields(e+".InnerNodes", access+"["+i+"]", elem, p, "")
+			if err != nil {
+				return err
+			}
+
+			g.printf("%s.InnerNodes = append(%s.InnerNodes, %s)\n", n, n, e)
+			g.printf("}\n")
+			g.printf("%s = append(%s, %s)\n", out, out, n)
+
+		default:
+			return fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+		}
+
+		g.printf("}\n")
+
+	case *types.Map:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+
+	return nil
+}
+
+func (g *generator) generateUnmarshal(name string, st *types.Struct) error {
+	g.vars = 0
+	g.printf("// UnmarshalEnv sets %s's fields from inner nodes of n\n", name)
+	g.printf("// and validates result with evon.Validate\n")
+	g.printf("func (v *%s) UnmarshalEnv(n *evon.Node) error {\n", name)
+
+	err := g.unmarshalStruct("n", "v", st, true)
+	if err != nil {
+		return err
+	}
+
+	g.printf("}\n\n")
+	return nil
+}
+
+// unmarshalStruct writes body of function that walks nodes of n
+// and sets fields of recv. Body returns error.
+// If validate is set, recv is validated when every field was set
+func (g *generator) unmarshalStruct(n, recv string, st *types.Struct, validate bool) error {
+	required, err := requiredFields(st, "")
+	if err != nil {
+		return err
+	}
+
+	errs := g.newVar("errs")
+	name := g.newVar("name")
+	node := g.newVar("node")
+	present := g.newVar("present")
+
+	g.printf("var %s []error\n", errs)
+	if len(required) != 0 {
+		g.printf("var %s [%d]bool\n", present, len(required))
+	}
+	g.printf("evonWalkNodes(%s, func(%s string, %s *evon.Node) {\n", n, name, node)
+	g.printf("var err error\n")
+	g.printf("switch %s {\n", name)
+
+	err = g.unmarshalFields(node, recv, st, "", nil)
+	if err != nil {
+		return err
+	}
+
+	g.printf("}\n")
+	g.printf("if err != nil {\n%s = append(%s, err)\n}\n", errs, errs)
+
+	if len(required) != 0 {
+		g.printf("if %s.Value != nil || len(%s.InnerNodes) != 0 {\n", node, node)
+		g.printf("switch %s {\n", name)
+		for i, r := range required {
+			g.printf("case %q:\n%s[%d] = true\n", r, present, i)
+		}
+		g.printf("}\n}\n")
+	}
+	g.printf("})\n")
+
+	if len(required) != 0 {
+		i := g.newVar("i")
+		r := g.newVar("r")
+		g.printf("for %s, %s := range [...]string{%s} {\n", i, r, quoteAll(required))
+		g.printf("if !%s[%s] {\n", present, i)
+		g.printf("%s = append(%s, &evon.MissingRequiredError{Path: evonJoinPath(%s, %s)})\n", errs, errs, n, r)
+		g.printf("}\n}\n")
+	}
+
+	if validate {
+		g.printf("if len(%s) != 0 {\nreturn evonErrorOrNil(%s)\n}\n", errs, errs)
+		g.printf("return evon.Validate(%s.Name, %s)\n", n, recv)
+		return nil
+	}
+
+	g.printf("return evonErrorOrNil(%s)\n", errs)
+
+	return nil
+}
+
+// requiredFields returns names of fields tagged as required
+// including fields of nested structs, but not of slice elements
+// which are checked by their own unmarshal
+func requiredFields(st *types.Struct, prefix string) ([]string, error) {
+	fields, err := structFields(st)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []string
+	for _, f := range fields {
+		name := prefix + f.name
+		if f.required {
+			out = append(out, name)
+		}
+
+		t := f.tp
+		if p, ok := t.Underlying().(*types.Pointer); ok {
+			t = p.Elem()
+		}
+
+		u, ok := t.Underlying().(*types.Struct)
+		if !ok || isTime(t) || hasPointerMethod(t, "UnmarshalText") {
+			continue
+		}
+
+		nested, err := requiredFields(u, name+"_")
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, nested...)
+	}
+
+	return out, nil
+}
+
+func quoteAll(ss []string) string {
+	quoted := make([]string, 0, len(ss))
+	for _, s := range ss {
+		quoted = append(quoted, strconv.Quote(s))
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+func (g *generator) unmarshalFields(node, recv string, st *types.Struct, prefix string, guards []string) error {
+	fields, err := structFields(st)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		err = g.unmarshalValue(node, recv+"."+f.goName, f.tp, prefix+f.name, guards)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.goName, err)
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) unmarshalValue(node, access string, t types.Type, name string, guards []string) error {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		if _, ok = p.Elem().Underlying().(*types.Pointer); ok {
+			return fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+		}
+
+		guards = append(guards[:len(guards):len(guards)], access+" != nil")
+		return g.unmarshalValue(node, deref(access, p.Elem()), p.Elem(), name, guards)
+	}
+
+	var call string
+	switch u := t.Underlying().(type) {
+	case *types.Struct:
+		switch {
+		case isTime(t):
+			call = fmt.Sprintf("evonParseTime(%s, %s)", node, addr(access))
+		case hasPointerMethod(t, "UnmarshalText"):
+			call = fmt.Sprintf("evonParseText(%s, %s)", node, addr(access))
+		default:
+			return g.unmarshalFields(node, access, u, name+"_", guards)
+		}
+
+	case *types.Slice:
+		if hasPointerMethod(t, "UnmarshalEnv") {
+			return fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+		}
+
+		parse, err := g.elemParser(u.Elem())
+		if err != nil {
+			return err
+		}
+
+		if parse == "" {
+			g.printf("case %q:\n", name)
+			g.writeGuarded(guards, func() {
+				g.printf("err = evonParseSlice(%s, %s, func(n *evon.Node, elem *%s) error {\n", node, addr(access), g.typeName(u.Elem()))
+				err = g.unmarshalStruct("n", "elem", u.Elem().Underlying().(*types.Struct), false)
+				g.printf("})\n")
+			})
+			return err
+		}
+
+		call = fmt.Sprintf("evonParseSlice(%s, %s, %s)", node, addr(access), parse)
+
+	case *types.Map:
+		return fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+
+	default:
+		parse := g.valueParser(t)
+		if parse == "" {
+			return nil
+		}
+
+		call = fmt.Sprintf("%s(%s, %s)", parse, node, addr(access))
+	}
+
+	g.printf("case %q:\n", name)
+	g.writeGuarded(guards, func() {
+		g.printf("err = %s\n", call)
+	})
+
+	return nil
+}
+
+func (g *generator) writeGuarded(guards []string, f func()) {
+	if len(guards) != 0 {
+		g.printf("if %s {\n", strings.Join(guards, " && "))
+	}
+
+	f()
+
+	if len(guards) != 0 {
+		g.printf("}\n")
+	}
+}
+
+// elemParser returns instantiated parse function for slice element.
+// Empty string is returned for struct elements
+func (g *generator) elemParser(t types.Type) (string, error) {
+	if _, ok := t.Underlying().(*types.Struct); ok {
+		if isTime(t) || hasPointerMethod(t, "UnmarshalText") {
+			return "", fmt.Errorf("%w: slice of %s", ErrUnsupportedType, t)
+		}
+
+		return "", nil
+	}
+
+	parse := g.valueParser(t)
+	if parse == "" {
+		return "", fmt.Errorf("%w: slice of %s", ErrUnsupportedType, t)
+	}
+
+	if parse == "evonParseText" {
+		return fmt.Sprintf("func(n *evon.Node, elem *%s) error {\nreturn evonParseText(n, elem)\n}", g.typeName(t)), nil
+	}
+
+	return fmt.Sprintf("%s[%s]", parse, g.typeName(t)), nil
+}
+
+// valueParser returns name of parse function for types
+// stored in a single node: basic types and text unmarshalers
+func (g *generator) valueParser(t types.Type) string {
+	if hasPointerMethod(t, "UnmarshalText") {
+		return "evonParseText"
+	}
+
+	b, ok := t.Underlying().(*types.Basic)
+	if !ok {
+		return ""
+	}
+
+	info := b.Info()
+	switch {
+	case info&types.IsString != 0:
+		return "evonParseString"
+	case info&types.IsBoolean != 0:
+		return "evonParseBool"
+	case info&types.IsInteger != 0 && info&types.IsUnsigned != 0:
+		return "evonParseUint"
+	case info&types.IsInteger != 0:
+		if named, ok := t.(*types.Named); ok && named.Obj().Name() == "Duration" {
+			return "evonParseDuration"
+		}
+		return "evonParseInt"
+	case info&types.IsFloat != 0:
+		return "evonParseFloat"
+	default:
+		return ""
+	}
+}
+
+// deref returns expression of value behind pointer.
+// Fields of structs are accessed through pointer as is
+func deref(access string, elem types.Type) string {
+	if _, ok := elem.Underlying().(*types.Struct); ok && !isTime(elem) {
+		return access
+	}
+
+	return "*" + access
+}
+
+// addr returns expression of pointer to value
+func addr(access string) string {
+	if strings.HasPrefix(access, "*") {
+		return access[1:]
+	}
+
+	return "&" + access
+}
+
+func zeroValue(b *types.Basic) (string, bool) {
+	info := b.Info()
+	switch {
+	case info&types.IsString != 0:
+		return `""`, true
+	case info&types.IsBoolean != 0:
+		return "false", true
+	case info&(types.IsInteger|types.IsFloat) != 0:
+		return "0", true
+	default:
+		return "", false
+	}
+}
+
+func isTime(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == "time" && obj.Name() == "Time"
+}
+
+func hasMethod(t types.Type, name string) bool {
+	return types.NewMethodSet(t).Lookup(nil, name) != nil
+}
+
+func hasPointerMethod(t types.Type, name string) bool {
+	return types.NewMethodSet(types.NewPointer(t)).Lookup(nil, name) != nil
+}
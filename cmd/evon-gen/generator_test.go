@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GenerateGolden(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join("..", "..", "internal", "gentest")
+
+	actual, err := Generate(dir, defaultOutput, nil)
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile(filepath.Join(dir, defaultOutput))
+	require.NoError(t, err)
+
+	require.Equal(t, string(expected), string(actual),
+		"generated code is outdated: run go generate ./internal/gentest")
+}
+
+func Test_GenerateErrors(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		src       string
+		typeNames []string
+		err       string
+	}
+
+	tests := map[string]testCase{
+		"NO_TYPES": {
+			src: `package p
+type Config struct{}`,
+			err: "no types to generate",
+		},
+		"NOT_STRUCT": {
+			src: `package p
+type Config int`,
+			typeNames: []string{"Config"},
+			err:       "Config is not a struct",
+		},
+		"MAP": {
+			src: `package p
+//evon:generate
+type Config struct {
+	Labels map[string]string
+}`,
+			err: "unsupported type",
+		},
+		"UNEXPORTED": {
+			src: `package p
+//evon:generate
+type Config struct {
+	secret string
+}`,
+			err: "unexported field secret",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			err := os.WriteFile(filepath.Join(dir, "config.go"), []byte(tc.src), 0o644)
+			require.NoError(t, err)
+
+			_, err = Generate(dir, defaultOutput, tc.typeNames)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,339 @@
+// Package helpers holds functions copied by evon-gen into every generated file.
+// They convert values the same way as reflective evon.Unmarshal and evon.Marshal do,
+// so generated code doesn't depend on unexported parts of evon.
+// Everything after imports is copied as is
+package helpers
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.redsock.ru/evon"
+)
+
+var evonErrOverflow = errors.New("value out of range")
+
+// evonWalkNodes calls f for every inner node of root
+// with node's name relative to root's name
+// e.g. for root "APP" node "APP_DB_PORT" is passed as "DB_PORT"
+func evonWalkNodes(root *evon.Node, f func(name string, n *evon.Node)) {
+	prefix := root.Name
+	if prefix != "" {
+		prefix += evon.ObjectSplitter
+	}
+
+	var walk func(nodes []*evon.Node)
+	walk = func(nodes []*evon.Node) {
+		for _, n := range nodes {
+			f(strings.TrimPrefix(n.Name, prefix), n)
+			walk(n.InnerNodes)
+		}
+	}
+
+	walk(root.InnerNodes)
+}
+
+// evonJoinPath joins name of root node with relative name
+func evonJoinPath(root *evon.Node, name string) string {
+	if root.Name == "" {
+		return name
+	}
+
+	return root.Name + evon.ObjectSplitter + name
+}
+
+func evonErrorOrNil(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return evon.MultiError(errs)
+	}
+}
+
+func evonTypeError(n *evon.Node, tp reflect.Type, err error) *evon.UnmarshalTypeError {
+	return &evon.UnmarshalTypeError{
+		Path:   n.Name,
+		Value:  evonValueString(n.Value),
+		GoType: tp,
+		Err:    err,
+	}
+}
+
+// evonValueString returns env representation of node's value
+func evonValueString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return evonFormatTime(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func evonString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int:
+		return strconv.FormatInt(v.Int(), 10)
+	default:
+		if !v.IsValid() {
+			return ""
+		}
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func evonParseString[T ~string](n *evon.Node, dst *T) error {
+	*dst = T(evonString(reflect.ValueOf(n.Value)))
+	return nil
+}
+
+func evonParseBool[T ~bool](n *evon.Node, dst *T) error {
+	var b bool
+	var err error
+
+	switch v := reflect.ValueOf(n.Value); v.Kind() {
+	case reflect.Bool:
+		b = v.Bool()
+	case reflect.String:
+		if v.String() != "" {
+			b, err = strconv.ParseBool(v.String())
+		}
+	case reflect.Invalid:
+	default:
+		return &evon.UnsupportedTypeError{Path: n.Name, Kind: v.Kind()}
+	}
+	if err != nil {
+		return evonTypeError(n, reflect.TypeFor[T](), err)
+	}
+
+	*dst = T(b)
+	return nil
+}
+
+func evonParseInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64](n *evon.Node, dst *T) error {
+	var i int64
+	var err error
+
+	switch v := reflect.ValueOf(n.Value); v.Kind() {
+	case reflect.String:
+		if v.String() != "" {
+			i, err = strconv.ParseInt(v.String(), 10, 64)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i = v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i = int64(v.Uint())
+	case reflect.Invalid:
+	default:
+		return &evon.UnsupportedTypeError{Path: n.Name, Kind: v.Kind()}
+	}
+	if err != nil {
+		return evonTypeError(n, reflect.TypeFor[T](), err)
+	}
+
+	out := T(i)
+	if int64(out) != i {
+		return evonTypeError(n, reflect.TypeFor[T](), evonErrOverflow)
+	}
+
+	*dst = out
+	return nil
+}
+
+func evonParseUint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr](n *evon.Node, dst *T) error {
+	var u uint64
+	var err error
+
+	switch v := reflect.ValueOf(n.Value); v.Kind() {
+	case reflect.String:
+		if v.String() != "" {
+			u, err = strconv.ParseUint(v.String(), 10, 64)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < 0 {
+			err = evonErrOverflow
+		}
+		u = uint64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u = v.Uint()
+	case reflect.Invalid:
+	default:
+		return &evon.UnsupportedTypeError{Path: n.Name, Kind: v.Kind()}
+	}
+	if err != nil {
+		return evonTypeError(n, reflect.TypeFor[T](), err)
+	}
+
+	out := T(u)
+	if uint64(out) != u {
+		return evonTypeError(n, reflect.TypeFor[T](), evonErrOverflow)
+	}
+
+	*dst = out
+	return nil
+}
+
+func evonParseFloat[T ~float32 | ~float64](n *evon.Node, dst *T) error {
+	var f float64
+	var err error
+
+	switch v := reflect.ValueOf(n.Value); v.Kind() {
+	case reflect.String:
+		if v.String() != "" {
+			f, err = strconv.ParseFloat(v.String(), 64)
+		}
+	case reflect.Float32, reflect.Float64:
+		f = v.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f = float64(v.Uint())
+	case reflect.Invalid:
+	default:
+		return &evon.UnsupportedTypeError{Path: n.Name, Kind: v.Kind()}
+	}
+	if err != nil {
+		return evonTypeError(n, reflect.TypeFor[T](), err)
+	}
+
+	out := T(f)
+	if math.IsInf(float64(out), 0) && !math.IsInf(f, 0) {
+		return evonTypeError(n, reflect.TypeFor[T](), evonErrOverflow)
+	}
+
+	*dst = out
+	return nil
+}
+
+func evonParseDuration[T ~int64](n *evon.Node, dst *T) error {
+	var d int64
+	var err error
+
+	switch v := reflect.ValueOf(n.Value); v.Kind() {
+	case reflect.String:
+		if v.String() != "" {
+			var parsed time.Duration
+			parsed, err = time.ParseDuration(v.String())
+			d = int64(parsed)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		d = v.Int()
+	case reflect.Invalid:
+	default:
+		return &evon.UnsupportedTypeError{Path: n.Name, Kind: v.Kind()}
+	}
+	if err != nil {
+		return evonTypeError(n, reflect.TypeFor[T](), err)
+	}
+
+	*dst = T(d)
+	return nil
+}
+
+// evonParseTime accepts the same formats as reflective unmarshal
+func evonParseTime(n *evon.Node, dst *time.Time) error {
+	switch v := n.Value.(type) {
+	case time.Time:
+		*dst = v
+	case string:
+		if v == "" {
+			return nil
+		}
+
+		for _, layout := range []string{time.DateOnly, time.DateTime, time.RFC3339Nano} {
+			t, err := time.Parse(layout, v)
+			if err == nil {
+				*dst = t
+				return nil
+			}
+		}
+
+		return evonTypeError(n, reflect.TypeFor[time.Time](), nil)
+	}
+
+	return nil
+}
+
+func evonParseText(n *evon.Node, dst encoding.TextUnmarshaler) error {
+	err := dst.UnmarshalText([]byte(evonString(reflect.ValueOf(n.Value))))
+	if err != nil {
+		return evonTypeError(n, reflect.TypeOf(dst).Elem(), err)
+	}
+
+	return nil
+}
+
+// evonParseSlice fills slice either from comma separated value
+// or from inner nodes "_[i]" of n
+func evonParseSlice[T any](n *evon.Node, dst *[]T, parse func(n *evon.Node, dst *T) error) error {
+	if len(n.InnerNodes) == 0 && n.Value != nil {
+		str := evonString(reflect.ValueOf(n.Value))
+		if str == "" {
+			return nil
+		}
+
+		parts := strings.Split(str, ",")
+		out := make([]T, len(parts))
+		for i, part := range parts {
+			err := parse(&evon.Node{Name: n.Name, Value: part}, &out[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		*dst = out
+		return nil
+	}
+
+	for _, e := range n.InnerNodes {
+		var elem T
+		err := parse(e, &elem)
+		if err != nil {
+			return err
+		}
+
+		*dst = append(*dst, elem)
+	}
+
+	return nil
+}
+
+// evonFormatTime keeps only significant part of time
+// e.g. date without time of day is formatted as time.DateOnly
+func evonFormatTime(t time.Time) string {
+	if t.Nanosecond() != 0 {
+		return t.Format(time.RFC3339Nano)
+	}
+
+	if t.Hour() != 0 ||
+		t.Minute() != 0 ||
+		t.Second() != 0 {
+		return t.Format(time.DateTime)
+	}
+
+	return t.Format(time.DateOnly)
+}
+
+// evonFormatSlice joins elements of slice of basic types with comma
+func evonFormatSlice[T any](s []T) string {
+	parts := make([]string, 0, len(s))
+	for _, e := range s {
+		parts = append(parts, fmt.Sprint(e))
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,56 @@
+// Command evon-gen generates reflection-free MarshalEnv and UnmarshalEnv methods
+// for structs of a package.
+//
+// Structs are selected with -type flag or with "//evon:generate" comment
+// e.g.
+//
+//	//go:generate go run go.redsock.ru/evon/cmd/evon-gen
+//
+//	//evon:generate
+//	type Config struct {
+//		Port int `evon:"PORT"`
+//	}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const defaultOutput = "evon_gen.go"
+
+func main() {
+	typeNames := flag.String("type", "", "comma separated list of struct names. Annotated structs are used if empty")
+	output := flag.String("output", defaultOutput, "output file name")
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	var types []string
+	if *typeNames != "" {
+		types = strings.Split(*typeNames, ",")
+	}
+
+	outPath := *output
+	if !filepath.IsAbs(outPath) {
+		outPath = filepath.Join(dir, outPath)
+	}
+
+	src, err := Generate(dir, filepath.Base(outPath), types)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "evon-gen:", err)
+		os.Exit(1)
+	}
+
+	err = os.WriteFile(outPath, src, 0o644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "evon-gen:", err)
+		os.Exit(1)
+	}
+}
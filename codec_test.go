@@ -0,0 +1,86 @@
+package evon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testCodec struct {
+	encode func(n *Node) ([]byte, error)
+	decode func(data []byte) (*Node, error)
+	// tree means that node can't have both value and inner nodes
+	tree bool
+}
+
+func testCodecs() map[string]testCodec {
+	return map[string]testCodec{
+		"toml": {encode: ToTOML, decode: FromTOML, tree: true},
+		"ini":  {encode: ToINI, decode: FromINI, tree: true},
+		"json": {
+			encode: func(n *Node) ([]byte, error) { return ToJSON(n) },
+			decode: FromJSON,
+			tree:   true,
+		},
+		"json-strings-only": {
+			encode: func(n *Node) ([]byte, error) { return ToJSON(n, WithJSONStringsOnly()) },
+			decode: FromJSON,
+			tree:   true,
+		},
+		"properties": {encode: ToProperties, decode: FromProperties},
+		"yaml":       {encode: ToYAML, decode: FromYAML, tree: true},
+	}
+}
+
+// Test_CodecRoundTrip checks that every codec keeps values of nodes.
+// Format-specific cases are tested next to each codec
+func Test_CodecRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	fixtures := map[string][]byte{
+		"object":          fullObjectDotEnv,
+		"prefixed-object": prefixedExpectedDotEnv,
+		"simple-object":   simpleObjectDotEnv,
+		"matreshka":       matreshkaDotEnv,
+	}
+
+	for codecName, c := range testCodecs() {
+		for fixtureName, input := range fixtures {
+			t.Run(codecName+"/"+fixtureName, func(t *testing.T) {
+				t.Parallel()
+
+				expected := ParseToNodes(input)[""]
+
+				data, err := c.encode(expected)
+				require.NoError(t, err)
+
+				actual, err := c.decode(data)
+				require.NoError(t, err)
+				require.Equal(t, NodeDiff{}, Diff(expected, actual), string(data))
+			})
+		}
+	}
+}
+
+func Test_CodecValueWithInnerNodes(t *testing.T) {
+	t.Parallel()
+
+	for name, c := range testCodecs() {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root := ParseToNodes([]byte("A=1\nA_B=2\n"))[""]
+
+			data, err := c.encode(root)
+			if c.tree {
+				require.ErrorContains(t, err, "node A has both value and inner nodes")
+				return
+			}
+			require.NoError(t, err)
+
+			actual, err := c.decode(data)
+			require.NoError(t, err)
+			require.Equal(t, NodeDiff{}, Diff(root, actual), string(data))
+		})
+	}
+}
@@ -1,35 +1,124 @@
 package evon
 
+import (
+	"sort"
+	"strings"
+)
+
 type NodeDiff struct {
-	NewNodes     []*Node
-	RemovedNodes []*Node
+	Added   []*Node
+	Changed []NodeChange
+	Removed []*Node
+}
+
+// NodeChange describes node that exists in both trees with different values
+type NodeChange struct {
+	Path string
+	Old  any
+	New  any
+}
+
+// IsEmpty returns true when there is no difference
+func (d NodeDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Changed) == 0 && len(d.Removed) == 0
+}
+
+// WithPrefix returns part of diff related to nodes under prefix
+// e.g. prefix "SERVERS" matches "SERVERS" and "SERVERS_REST_PORT" but not "SERVERS-V2_PORT".
+// Empty prefix matches every node
+func (d NodeDiff) WithPrefix(prefix string) NodeDiff {
+	if prefix == "" {
+		return d
+	}
+
+	matches := func(path string) bool {
+		return path == prefix || strings.HasPrefix(path, prefix+ObjectSplitter)
+	}
+
+	out := NodeDiff{}
+	for _, n := range d.Added {
+		if matches(n.Name) {
+			out.Added = append(out.Added, n)
+		}
+	}
+
+	for _, c := range d.Changed {
+		if matches(c.Path) {
+			out.Changed = append(out.Changed, c)
+		}
+	}
+
+	for _, n := range d.Removed {
+		if matches(n.Name) {
+			out.Removed = append(out.Removed, n)
+		}
+	}
+
+	return out
 }
 
-// Diff returns difference between new and old nodes
+// Diff returns difference between new and old nodes.
+// Only nodes holding value are compared. Values are compared
+// by their env representation, so "5432" is equal to uint64(5432)
 // e.g.
 //
-//	if [new] contains sub-node that is not presented in [old] - it goes to NewNodes
-//	if [old] contains bun-node that is not presented in [new] - it goes to RemovedNodes
+//	if [new] contains sub-node that is not presented in [old] - it goes to Added
+//	if [old] contains sub-node that is not presented in [new] - it goes to Removed
+//	if both contain sub-node with different values - it goes to Changed
+//
+// Every list is sorted by node path
 func Diff(old, new *Node) NodeDiff {
 	nd := NodeDiff{}
 
 	oldStorage := NodesToStorage(old)
 	newStorage := NodesToStorage(new)
 	for _, newNode := range newStorage {
+		if newNode.Value == nil {
+			continue
+		}
+
 		oldNode, exists := oldStorage[newNode.Name]
-		if !exists {
-			nd.NewNodes = append(nd.NewNodes, newNode)
-		} else if oldNode.Value != newNode.Value {
-			nd.NewNodes = append(nd.NewNodes, newNode)
+		if !exists || oldNode.Value == nil {
+			nd.Added = append(nd.Added, newNode)
+			continue
+		}
+
+		if !valuesEqual(oldNode.Value, newNode.Value) {
+			nd.Changed = append(nd.Changed, NodeChange{
+				Path: newNode.Name,
+				Old:  oldNode.Value,
+				New:  newNode.Value,
+			})
 		}
 	}
 
 	for _, oldNode := range oldStorage {
-		_, exists := newStorage[oldNode.Name]
-		if !exists {
-			nd.RemovedNodes = append(nd.RemovedNodes, oldNode)
+		if oldNode.Value == nil {
+			continue
+		}
+
+		newNode, exists := newStorage[oldNode.Name]
+		if !exists || newNode.Value == nil {
+			nd.Removed = append(nd.Removed, oldNode)
 		}
 	}
 
+	sortNodes(nd.Added)
+	sortNodes(nd.Removed)
+	sort.Slice(nd.Changed, func(i, j int) bool {
+		return nd.Changed[i].Path < nd.Changed[j].Path
+	})
+
 	return nd
 }
+
+// valuesEqual compares values by their env representation
+func valuesEqual(a, b any) bool {
+	return valueToString(a) == valueToString(b)
+}
+
+func sortNodes(nodes []*Node) {
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+}
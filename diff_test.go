@@ -48,13 +48,13 @@ func Test_Diff(t *testing.T) {
 	actual := Diff(oldNodes, newNodes)
 
 	expected := NodeDiff{
-		NewNodes: []*Node{
+		Added: []*Node{
 			{
 				Name:  "inner-nodes_added-string",
 				Value: "14",
 			},
 		},
-		RemovedNodes: []*Node{
+		Removed: []*Node{
 			{
 				Name:  "inner-nodes_deleted-string",
 				Value: "13",
@@ -64,3 +64,79 @@ func Test_Diff(t *testing.T) {
 
 	require.Equal(t, expected, actual)
 }
+
+func Test_DiffChanged(t *testing.T) {
+	oldNodes := &Node{
+		InnerNodes: []*Node{
+			{
+				Name:  "port",
+				Value: "5432",
+			},
+			{
+				Name:  "host",
+				Value: "localhost",
+			},
+			{
+				Name:  "ports",
+				Value: []int{1, 2},
+			},
+			{
+				Name:  "removed",
+				Value: "1",
+			},
+		},
+	}
+
+	newNodes := &Node{
+		InnerNodes: []*Node{
+			{
+				Name:  "port",
+				Value: uint64(5432),
+			},
+			{
+				Name:  "host",
+				Value: "0.0.0.0",
+			},
+			{
+				Name:  "ports",
+				Value: []int{1, 3},
+			},
+			{
+				Name:  "added",
+				Value: "2",
+			},
+		},
+	}
+
+	actual := Diff(oldNodes, newNodes)
+
+	expected := NodeDiff{
+		Added: []*Node{
+			{
+				Name:  "added",
+				Value: "2",
+			},
+		},
+		Changed: []NodeChange{
+			{
+				Path: "host",
+				Old:  "localhost",
+				New:  "0.0.0.0",
+			},
+			{
+				Path: "ports",
+				Old:  []int{1, 2},
+				New:  []int{1, 3},
+			},
+		},
+		Removed: []*Node{
+			{
+				Name:  "removed",
+				Value: "1",
+			},
+		},
+	}
+
+	require.Equal(t, expected, actual)
+	require.True(t, Diff(oldNodes, oldNodes).IsEmpty())
+}
@@ -0,0 +1,164 @@
+package evon
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported type")
+	ErrMissingRequired = errors.New("missing required variable")
+	ErrUnknownKey      = errors.New("unknown variable")
+
+	errOverflow = errors.New("value out of range")
+)
+
+// UnmarshalTypeError describes variable which value can't be converted into Go type
+type UnmarshalTypeError struct {
+	Path   string
+	Value  string
+	GoType reflect.Type
+	Err    error
+}
+
+func newUnmarshalTypeError(src *Node, tp reflect.Type, err error) *UnmarshalTypeError {
+	return &UnmarshalTypeError{
+		Path:   src.Name,
+		Value:  valueToString(src.Value),
+		GoType: tp,
+		Err:    err,
+	}
+}
+
+func (e *UnmarshalTypeError) Error() string {
+	msg := fmt.Sprintf("cannot unmarshal %q of %s into Go value of type %s", e.Value, e.Path, e.GoType)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+
+	return msg
+}
+
+func (e *UnmarshalTypeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *UnmarshalTypeError) prependPath(prefix string) {
+	e.Path = joinPath(prefix, e.Path)
+}
+
+// UnsupportedTypeError describes Go value that can't be marshalled or unmarshalled
+type UnsupportedTypeError struct {
+	Path string
+	Kind reflect.Kind
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("%s %s at %s", ErrUnsupportedType, e.Kind, e.Path)
+}
+
+func (e *UnsupportedTypeError) Is(target error) bool {
+	return target == ErrUnsupportedType
+}
+
+func (e *UnsupportedTypeError) prependPath(prefix string) {
+	e.Path = joinPath(prefix, e.Path)
+}
+
+// MissingRequiredError describes field tagged as required that has no variable
+type MissingRequiredError struct {
+	Path string
+}
+
+func (e *MissingRequiredError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrMissingRequired, e.Path)
+}
+
+func (e *MissingRequiredError) Is(target error) bool {
+	return target == ErrMissingRequired
+}
+
+func (e *MissingRequiredError) prependPath(prefix string) {
+	e.Path = joinPath(prefix, e.Path)
+}
+
+// UnknownKeyError describes variable that doesn't match any field of destination.
+// Returned only when WithDisallowUnknownKeys is passed
+type UnknownKeyError struct {
+	Path string
+}
+
+func (e *UnknownKeyError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrUnknownKey, e.Path)
+}
+
+func (e *UnknownKeyError) Is(target error) bool {
+	return target == ErrUnknownKey
+}
+
+func (e *UnknownKeyError) prependPath(prefix string) {
+	e.Path = joinPath(prefix, e.Path)
+}
+
+// MultiError aggregates errors. Supports errors.Is and errors.As for every inner error
+type MultiError []error
+
+func (m MultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func (m MultiError) Unwrap() []error {
+	return m
+}
+
+// errorOrNil returns nil for empty MultiError
+// and the only error if there is just one
+func (m MultiError) errorOrNil() error {
+	switch len(m) {
+	case 0:
+		return nil
+	case 1:
+		return m[0]
+	default:
+		return m
+	}
+}
+
+// pathError is implemented by errors that keep variable's path
+type pathError interface {
+	error
+	prependPath(prefix string)
+}
+
+// prependErrorPath adds prefix to path of every path-aware error inside err.
+// Used when nested values (e.g. slice elements) are unmarshalled with relative names
+func prependErrorPath(err error, prefix string) {
+	if err == nil {
+		return
+	}
+
+	if pe, ok := err.(pathError); ok {
+		pe.prependPath(prefix)
+	}
+
+	switch u := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, inner := range u.Unwrap() {
+			prependErrorPath(inner, prefix)
+		}
+	case interface{ Unwrap() error }:
+		prependErrorPath(u.Unwrap(), prefix)
+	}
+}
+
+func hasPath(err error) bool {
+	var pe pathError
+	return errors.As(err, &pe)
+}
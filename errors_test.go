@@ -0,0 +1,166 @@
+package evon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type errorsTestServer struct {
+	Name string `evon:"NAME"`
+	Port int8   `evon:"PORT"`
+}
+
+type errorsTestConfig struct {
+	Host    string             `evon:"HOST,required"`
+	Port    uint16             `evon:"PORT"`
+	Debug   bool               `evon:"DEBUG"`
+	Servers []errorsTestServer `evon:"SERVERS"`
+}
+
+func Test_UnmarshalTypeError(t *testing.T) {
+	t.Parallel()
+
+	t.Run("INVALID_VALUE", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg errorsTestConfig
+		err := UnmarshalWithPrefix("APP", []byte(`APP_HOST=localhost
+APP_DEBUG=sure`), &cfg)
+
+		var typeErr *UnmarshalTypeError
+		require.ErrorAs(t, err, &typeErr)
+		require.Equal(t, "APP_DEBUG", typeErr.Path)
+		require.Equal(t, "sure", typeErr.Value)
+		require.Equal(t, reflect.TypeOf(true), typeErr.GoType)
+	})
+
+	t.Run("OVERFLOW", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg errorsTestConfig
+		err := UnmarshalWithPrefix("APP", []byte(`APP_HOST=localhost
+APP_PORT=70000`), &cfg)
+
+		var typeErr *UnmarshalTypeError
+		require.ErrorAs(t, err, &typeErr)
+		require.Equal(t, "APP_PORT", typeErr.Path)
+		require.ErrorIs(t, err, errOverflow)
+	})
+
+	t.Run("SLICE_ELEMENT", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg errorsTestConfig
+		err := UnmarshalWithPrefix("APP", []byte(`APP_HOST=localhost
+APP_SERVERS_[0]_NAME=rest
+APP_SERVERS_[0]_PORT=80
+APP_SERVERS_[1]_NAME=grpc
+APP_SERVERS_[1]_PORT=500`), &cfg)
+
+		var typeErr *UnmarshalTypeError
+		require.ErrorAs(t, err, &typeErr)
+		require.Equal(t, "APP_SERVERS_[1]_PORT", typeErr.Path)
+		require.Equal(t, "500", typeErr.Value)
+	})
+
+	t.Run("MULTIPLE", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg errorsTestConfig
+		err := UnmarshalWithPrefix("APP", []byte(`APP_PORT=port
+APP_DEBUG=sure`), &cfg)
+
+		var multi MultiError
+		require.ErrorAs(t, err, &multi)
+		require.Len(t, multi, 3)
+		require.ErrorIs(t, err, ErrMissingRequired)
+
+		paths := make([]string, 0, 2)
+		for _, e := range multi[:2] {
+			var typeErr *UnmarshalTypeError
+			require.ErrorAs(t, e, &typeErr)
+			paths = append(paths, typeErr.Path)
+		}
+		require.Equal(t, []string{"APP_DEBUG", "APP_PORT"}, paths)
+	})
+}
+
+func Test_MissingRequiredError(t *testing.T) {
+	t.Parallel()
+
+	var cfg errorsTestConfig
+	err := UnmarshalWithPrefix("APP", []byte(`APP_PORT=80`), &cfg)
+	require.ErrorIs(t, err, ErrMissingRequired)
+
+	var reqErr *MissingRequiredError
+	require.ErrorAs(t, err, &reqErr)
+	require.Equal(t, "APP_HOST", reqErr.Path)
+}
+
+func Test_UnknownKeyError(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`APP_HOST=localhost
+APP_SERVERS_[0]_NAME=rest
+APP_HOTS=typo
+OTHER_HOST=not-ours`)
+
+	var cfg errorsTestConfig
+	require.NoError(t, UnmarshalWithPrefix("APP", src, &cfg))
+
+	err := UnmarshalWithPrefix("APP", src, &cfg, WithDisallowUnknownKeys())
+	require.ErrorIs(t, err, ErrUnknownKey)
+
+	var unknownErr *UnknownKeyError
+	require.ErrorAs(t, err, &unknownErr)
+	require.Equal(t, "APP_HOTS", unknownErr.Path)
+}
+
+func Test_UnsupportedTypeError(t *testing.T) {
+	t.Parallel()
+
+	type cfg struct {
+		Callbacks []func() `evon:"CALLBACKS"`
+	}
+
+	_, err := MarshalEnvWithPrefix("APP", &cfg{Callbacks: []func(){func() {}}})
+	require.ErrorIs(t, err, ErrUnsupportedType)
+
+	var typeErr *UnsupportedTypeError
+	require.ErrorAs(t, err, &typeErr)
+	require.Equal(t, "APP_CALLBACKS", typeErr.Path)
+	require.Equal(t, reflect.Func, typeErr.Kind)
+}
+
+func Test_UnsupportedValueTypeError(t *testing.T) {
+	t.Parallel()
+
+	src := NodesToStorage(&Node{
+		InnerNodes: []*Node{
+			{Name: "HOST", Value: "localhost"},
+			{Name: "PORT", Value: true},
+			{Name: "DEBUG", Value: 1},
+		},
+	})
+
+	var cfg errorsTestConfig
+	err := UnmarshalWithNodes(src, &cfg)
+	require.ErrorIs(t, err, ErrUnsupportedType)
+
+	var multi MultiError
+	require.ErrorAs(t, err, &multi)
+	require.Len(t, multi, 2)
+
+	kinds := map[string]reflect.Kind{}
+	for _, e := range multi {
+		var typeErr *UnsupportedTypeError
+		require.ErrorAs(t, e, &typeErr)
+		kinds[typeErr.Path] = typeErr.Kind
+	}
+	require.Equal(t, map[string]reflect.Kind{
+		"PORT":  reflect.Bool,
+		"DEBUG": reflect.Int,
+	}, kinds)
+}
@@ -0,0 +1,80 @@
+package evontest
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"go.redsock.ru/evon"
+)
+
+// Helpers below change process environment with t.Setenv,
+// so it is restored when test finishes.
+// Like t.Setenv they can't be used in parallel tests
+
+// Setenv sets variables for duration of test
+func Setenv(t testing.TB, vars map[string]string) {
+	t.Helper()
+
+	for k, v := range vars {
+		t.Setenv(k, v)
+	}
+}
+
+// SetenvFile sets variables of env file for duration of test
+// e.g.
+//
+//	evontest.SetenvFile(t, []byte("APP_NAME=matreshka\nAPP_PORT=8080"))
+func SetenvFile(t testing.TB, data []byte) {
+	t.Helper()
+
+	for name, n := range evon.ParseToNodes(data) {
+		if n.Value != nil {
+			t.Setenv(name, n.Value.(string))
+		}
+	}
+}
+
+// SetenvValue marshals v with prefix and sets result
+// as variables for duration of test
+func SetenvValue(t testing.TB, prefix string, v any) {
+	t.Helper()
+
+	root, err := evon.MarshalEnvWithPrefix(prefix, v)
+	if err != nil {
+		t.Fatalf("error marshalling %T: %v", v, err)
+		return
+	}
+
+	if root == nil {
+		return
+	}
+
+	SetenvFile(t, evon.Marshal(root.InnerNodes))
+}
+
+// ClearEnv unsets variables starting with prefix for duration of test.
+// Empty prefix is refused in order not to unset whole environment
+func ClearEnv(t testing.TB, prefix string) {
+	t.Helper()
+
+	if prefix == "" {
+		t.Fatalf("ClearEnv requires non-empty prefix")
+		return
+	}
+
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		// t.Setenv registers restoring of original value
+		t.Setenv(name, "")
+		err := os.Unsetenv(name)
+		if err != nil {
+			t.Fatalf("error unsetting %s: %v", name, err)
+			return
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package evontest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Setenv(t *testing.T) {
+	t.Run("set", func(t *testing.T) {
+		Setenv(t, map[string]string{"EVONTEST_A": "1"})
+		SetenvFile(t, []byte("# comment\nEVONTEST_B=2\n"))
+		SetenvValue(t, "EVONTEST", newTestConfig())
+
+		require.Equal(t, "1", os.Getenv("EVONTEST_A"))
+		require.Equal(t, "2", os.Getenv("EVONTEST_B"))
+		require.Equal(t, "matreshka", os.Getenv("EVONTEST_NAME"))
+		require.Equal(t, "5432", os.Getenv("EVONTEST_DB_PORT"))
+	})
+
+	_, ok := os.LookupEnv("EVONTEST_A")
+	require.False(t, ok)
+	_, ok = os.LookupEnv("EVONTEST_DB_PORT")
+	require.False(t, ok)
+}
+
+func Test_ClearEnv(t *testing.T) {
+	t.Setenv("EVONTEST_CLEAR_A", "1")
+	t.Setenv("EVONTEST-OTHER", "2")
+
+	t.Run("clear", func(t *testing.T) {
+		ClearEnv(t, "EVONTEST_")
+
+		_, ok := os.LookupEnv("EVONTEST_CLEAR_A")
+		require.False(t, ok)
+		require.Equal(t, "2", os.Getenv("EVONTEST-OTHER"))
+	})
+
+	require.Equal(t, "1", os.Getenv("EVONTEST_CLEAR_A"))
+}
+
+func Test_ClearEnvEmptyPrefix(t *testing.T) {
+	t.Setenv("EVONTEST_CLEAR_B", "1")
+
+	r := &recorder{}
+	ClearEnv(r, "")
+
+	require.Equal(t, []string{"ClearEnv requires non-empty prefix"}, r.errors)
+	require.Equal(t, "1", os.Getenv("EVONTEST_CLEAR_B"))
+}
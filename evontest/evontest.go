@@ -0,0 +1,219 @@
+// Package evontest provides helpers for testing types
+// that are marshalled to and unmarshalled from environment variables
+// e.g.
+//
+//	func Test_Config(t *testing.T) {
+//		cfg := NewConfig()
+//		evontest.AssertRoundTrip(t, cfg)
+//		evontest.AssertGolden(t, cfg, "testdata/config.env")
+//	}
+//
+// Golden files are rewritten by running tests with -evontest.update flag
+//
+//	go test ./... -evontest.update
+package evontest
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"go.redsock.ru/evon"
+)
+
+// update is namespaced in order not to clash with -update flag of tests using evontest
+var update = flag.Bool("evontest.update", false, "update golden files of evontest.AssertGolden")
+
+// AssertRoundTrip marshals v into env file, unmarshals it
+// into new value of the same type and checks that both values are equal.
+// v can be a struct or a pointer to struct
+func AssertRoundTrip(t testing.TB, v any) {
+	t.Helper()
+
+	expected, data, err := marshal(v)
+	if err != nil {
+		t.Errorf("error marshalling %T: %v", v, err)
+		return
+	}
+
+	tp := reflect.TypeOf(v)
+	isPtr := tp.Kind() == reflect.Pointer
+	if isPtr {
+		tp = tp.Elem()
+	}
+
+	dst := reflect.New(tp)
+	err = evon.Unmarshal(data, dst.Interface())
+	if err != nil {
+		t.Errorf("error unmarshalling %T from:\n%s\n%v", v, data, err)
+		return
+	}
+
+	actual := dst.Interface()
+	if !isPtr {
+		actual = dst.Elem().Interface()
+	}
+
+	if reflect.DeepEqual(v, actual) {
+		return
+	}
+
+	actualNodes, err := evon.MarshalEnv(actual)
+	if err != nil {
+		t.Errorf("error marshalling unmarshalled %T: %v", v, err)
+		return
+	}
+
+	d := evon.Diff(expected, actualNodes)
+	if d.IsEmpty() {
+		t.Errorf("%T is not equal after round trip:\nexpected: %#v\nactual:   %#v", v, v, actual)
+		return
+	}
+
+	t.Errorf("%T is not equal after round trip:\n%s", v, FormatDiff(d))
+}
+
+// AssertGolden marshals v and compares result with env file at path.
+// With -evontest.update flag file is rewritten instead
+func AssertGolden(t testing.TB, v any, path string) {
+	t.Helper()
+
+	_, actual, err := marshal(v)
+	if err != nil {
+		t.Errorf("error marshalling %T: %v", v, err)
+		return
+	}
+
+	if *update {
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err == nil {
+			err = os.WriteFile(path, actual, 0o644)
+		}
+		if err != nil {
+			t.Errorf("error updating golden file: %v", err)
+		}
+
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("golden file %s doesn't exist. Run tests with -evontest.update flag to create it", path)
+			return
+		}
+
+		t.Errorf("error reading golden file: %v", err)
+		return
+	}
+
+	if bytes.Equal(expected, actual) {
+		return
+	}
+
+	d := evon.Diff(evon.ParseToNodes(expected)[""], evon.ParseToNodes(actual)[""])
+	if d.IsEmpty() {
+		t.Errorf("%s differs from marshalled %T in lines order or formatting:\nexpected:\n%s\nactual:\n%s", path, v, expected, actual)
+		return
+	}
+
+	t.Errorf("%s differs from marshalled %T:\n%s", path, v, FormatDiff(d))
+}
+
+// AssertNodesEqual checks that trees have the same values.
+// Values are compared by their env representation like in evon.Diff
+func AssertNodesEqual(t testing.TB, expected, actual *evon.Node) {
+	t.Helper()
+
+	d := evon.Diff(expected, actual)
+	if d.IsEmpty() {
+		return
+	}
+
+	t.Errorf("nodes are not equal:\n%s", FormatDiff(d))
+}
+
+// FormatDiff renders diff as a tree of changed nodes
+// e.g.
+//
+//	  DB
+//	-   HOST=localhost
+//	~   PORT=5432 -> 5433
+//	+ NAME=app
+func FormatDiff(d evon.NodeDiff) string {
+	type line struct {
+		path string
+		mark byte
+		text func(name string) string
+	}
+
+	lines := make([]line, 0, len(d.Added)+len(d.Changed)+len(d.Removed))
+	for _, n := range d.Added {
+		lines = append(lines, line{n.Name, '+', func(name string) string {
+			return fmt.Sprintf("%s=%v", name, n.Value)
+		}})
+	}
+
+	for _, c := range d.Changed {
+		lines = append(lines, line{c.Path, '~', func(name string) string {
+			return fmt.Sprintf("%s=%v -> %v", name, c.Old, c.New)
+		}})
+	}
+
+	for _, n := range d.Removed {
+		lines = append(lines, line{n.Name, '-', func(name string) string {
+			return fmt.Sprintf("%s=%v", name, n.Value)
+		}})
+	}
+
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].path < lines[j].path
+	})
+
+	sb := &strings.Builder{}
+	var parents []string
+	for _, l := range lines {
+		parts := strings.Split(l.path, evon.ObjectSplitter)
+		name := parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+
+		common := 0
+		for common < len(parents) && common < len(parts) && parents[common] == parts[common] {
+			common++
+		}
+
+		for depth := common; depth < len(parts); depth++ {
+			fmt.Fprintf(sb, "  %s%s\n", indent(depth), parts[depth])
+		}
+
+		fmt.Fprintf(sb, "%c %s%s\n", l.mark, indent(len(parts)), l.text(name))
+		parents = parts
+	}
+
+	return sb.String()
+}
+
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+func marshal(v any) (*evon.Node, []byte, error) {
+	root, err := evon.MarshalEnv(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if root == nil {
+		return nil, nil, fmt.Errorf("nothing to marshal in %T", v)
+	}
+
+	return root, evon.Marshal(root.InnerNodes), nil
+}
@@ -0,0 +1,150 @@
+package evontest
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go.redsock.ru/evon"
+)
+
+type testConfig struct {
+	Name    string        `evon:"NAME"`
+	Timeout time.Duration `evon:"TIMEOUT"`
+	Db      testDb        `evon:"DB"`
+	Tags    []string      `evon:"TAGS"`
+}
+
+type testDb struct {
+	Host string `evon:"HOST"`
+	Port int    `evon:"PORT"`
+}
+
+// lossyConfig loses Hidden field during round trip
+type lossyConfig struct {
+	Name   string `evon:"NAME"`
+	Hidden string `evon:"-"`
+}
+
+func newTestConfig() testConfig {
+	return testConfig{
+		Name:    "matreshka",
+		Timeout: 10 * time.Second,
+		Db: testDb{
+			Host: "localhost",
+			Port: 5432,
+		},
+		Tags: []string{"a", "b"},
+	}
+}
+
+// "update" is a golden-file flag commonly defined by tests using evontest.
+// Registering it proves that evontest's flag doesn't clash with it
+var _ = flag.Bool("update", false, "update golden files of consumer")
+
+// recorder captures failures of assertions
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func Test_AssertRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	cfg := newTestConfig()
+	AssertRoundTrip(t, cfg)
+	AssertRoundTrip(t, &cfg)
+
+	r := &recorder{TB: t}
+	AssertRoundTrip(r, lossyConfig{Name: "a", Hidden: "b"})
+	require.Len(t, r.errors, 1)
+	require.Contains(t, r.errors[0], "evontest.lossyConfig is not equal after round trip")
+	require.Contains(t, r.errors[0], `Hidden:"b"`)
+}
+
+func Test_AssertGolden(t *testing.T) {
+	t.Parallel()
+
+	AssertGolden(t, newTestConfig(), "testdata/config.env")
+}
+
+func Test_AssertGoldenMismatch(t *testing.T) {
+	t.Parallel()
+
+	if *update {
+		t.Skip("golden files are being updated")
+	}
+
+	golden, err := os.ReadFile("testdata/config.env")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "config.env")
+	require.NoError(t, os.WriteFile(path, golden, 0o644))
+
+	cfg := newTestConfig()
+	cfg.Db.Port = 5433
+	cfg.Name = ""
+
+	r := &recorder{TB: t}
+	AssertGolden(r, cfg, path)
+	require.Equal(t, []string{path + ` differs from marshalled evontest.testConfig:
+  DB
+~   PORT=5432 -> 5433
+~ NAME=matreshka -> 
+`}, r.errors)
+
+	r = &recorder{TB: t}
+	AssertGolden(r, cfg, filepath.Join(t.TempDir(), "missing.env"))
+	require.Len(t, r.errors, 1)
+	require.Contains(t, r.errors[0], "Run tests with -evontest.update flag to create it")
+}
+
+func Test_AssertGoldenUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.env")
+
+	*update = true
+	defer func() { *update = false }()
+
+	AssertGolden(t, newTestConfig(), path)
+
+	actual, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	expected, err := os.ReadFile("testdata/config.env")
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+}
+
+func Test_AssertNodesEqual(t *testing.T) {
+	t.Parallel()
+
+	expected := evon.ParseToNodes([]byte("A_B_C=1\nA_B_D=2\nA_E=3\nF=4\n"))[""]
+	actual := evon.ParseToNodes([]byte("A_B_C=1\nA_B_D=5\nF=4\nG=6\n"))[""]
+
+	AssertNodesEqual(t, expected, expected)
+
+	r := &recorder{TB: t}
+	AssertNodesEqual(r, expected, actual)
+	require.Equal(t, []string{`nodes are not equal:
+  A
+    B
+~     D=2 -> 5
+-   E=3
++ G=6
+`}, r.errors)
+}
@@ -0,0 +1,142 @@
+package evontest_test
+
+// Examples of README.md
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go.redsock.ru/evon"
+	"go.redsock.ru/evon/evontest"
+)
+
+type AppConfig struct {
+	AppInfo     AppInfo
+	DataSources DataSources
+}
+
+type AppInfo struct {
+	Name            string
+	Version         string
+	StartupDuration time.Duration
+}
+
+type Resource interface {
+	// GetName - returns Name defined in config file
+	GetName() string
+}
+
+type Postgres struct {
+	Name string `evon:"-"`
+	Host string
+	Port uint64
+}
+
+func (p *Postgres) GetName() string { return p.Name }
+
+type Redis struct {
+	Name string `evon:"-"`
+	Host string
+	Port uint16
+}
+
+func (r *Redis) GetName() string { return r.Name }
+
+func GetResourceByName(name string) Resource {
+	switch {
+	case strings.HasPrefix(name, "postgres"):
+		return &Postgres{Name: name}
+	case strings.HasPrefix(name, "redis"):
+		return &Redis{Name: name}
+	default:
+		return nil
+	}
+}
+
+type DataSources []Resource
+
+func (r DataSources) MarshalEnv(prefix string) ([]*evon.Node, error) {
+	if prefix != "" {
+		prefix += evon.ObjectSplitter
+	}
+
+	out := make([]*evon.Node, 0, len(r))
+	for _, resource := range r {
+		resourceName := strings.ReplaceAll(resource.GetName(), "_", "-")
+
+		node, err := evon.MarshalEnvWithPrefix(prefix+resourceName, resource)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, node)
+	}
+
+	return out, nil
+}
+
+func (r *DataSources) UnmarshalEnv(rootNode *evon.Node) error {
+	sources := make(DataSources, 0, len(rootNode.InnerNodes))
+	for _, dataSourceNode := range rootNode.InnerNodes {
+		name := strings.TrimPrefix(dataSourceNode.Name, rootNode.Name+evon.ObjectSplitter)
+		name = strings.ToLower(strings.ReplaceAll(name, "-", "_"))
+
+		dst := GetResourceByName(name)
+		if dst == nil {
+			continue
+		}
+
+		err := evon.NodeToStruct(dataSourceNode.Name, dataSourceNode, dst)
+		if err != nil {
+			return err
+		}
+
+		sources = append(sources, dst)
+	}
+
+	*r = sources
+
+	return nil
+}
+
+func newAppConfig() AppConfig {
+	return AppConfig{
+		AppInfo: AppInfo{
+			Name:            "matreshka",
+			Version:         "v0.0.1",
+			StartupDuration: 10 * time.Second,
+		},
+		DataSources: DataSources{
+			&Postgres{Name: "postgres", Host: "localhost", Port: 5432},
+			&Redis{Name: "redis_cache", Host: "localhost", Port: 6379},
+		},
+	}
+}
+
+func Test_MarshallingEnv(t *testing.T) {
+	t.Parallel()
+
+	cfg := newAppConfig()
+
+	evontest.AssertRoundTrip(t, &cfg)
+	evontest.AssertGolden(t, &cfg, "testdata/app_config.env")
+}
+
+func Test_LoadingFromProcessEnv(t *testing.T) {
+	evontest.ClearEnv(t, "MATRESHKA_")
+
+	expected := newAppConfig()
+	evontest.SetenvValue(t, "MATRESHKA", &expected)
+
+	var actual AppConfig
+	err := evon.NewLoader(
+		evon.WithPrefix("MATRESHKA"),
+		evon.WithProcessEnv(),
+	).Load(context.Background(), &actual)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+}
@@ -0,0 +1,271 @@
+package evon
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is a target of Encoder
+type Format int
+
+const (
+	// FormatEnv is the same as Marshal
+	// e.g. DB_HOST=localhost
+	FormatEnv Format = iota
+	// FormatComposeMap is a map form of docker-compose environment
+	// e.g.
+	//
+	//	environment:
+	//	  DB_HOST: localhost
+	FormatComposeMap
+	// FormatComposeList is a list form of docker-compose environment
+	// e.g.
+	//
+	//	environment:
+	//	  - DB_HOST=localhost
+	FormatComposeList
+	// FormatSystemd is a systemd unit Environment directive
+	// e.g. Environment="DB_HOST=localhost"
+	FormatSystemd
+	// FormatShell is a shell script of exports
+	// e.g. export DB_HOST='localhost'
+	// FieldSplitter "-" can't be part of shell variable name,
+	// so it's replaced with "_" e.g. APP-INFO_NAME is exported as APP_INFO_NAME.
+	// Names that become equal after replacement are reported as error
+	FormatShell
+	// FormatGitHubEnv is a heredoc for $GITHUB_ENV file of GitHub Actions
+	// e.g.
+	//
+	//	DB_HOST<<EOF
+	//	localhost
+	//	EOF
+	FormatGitHubEnv
+)
+
+var formatNames = map[Format]string{
+	FormatEnv:         "env",
+	FormatComposeMap:  "compose-map",
+	FormatComposeList: "compose-list",
+	FormatSystemd:     "systemd",
+	FormatShell:       "shell",
+	FormatGitHubEnv:   "github-env",
+}
+
+const gitHubEnvDelimiter = "EOF"
+
+var shellNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (f Format) String() string {
+	name, ok := formatNames[f]
+	if !ok {
+		return "Format(" + strconv.Itoa(int(f)) + ")"
+	}
+
+	return name
+}
+
+// ParseFormat returns Format by its name
+// e.g. "compose-map" -> FormatComposeMap
+func ParseFormat(name string) (Format, error) {
+	for f, n := range formatNames {
+		if n == name {
+			return f, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown format %q", name)
+}
+
+// Encoder writes nodes with values in the given Format.
+// Order of variables is the same as in Marshal
+// e.g.
+//
+//	evon.Encoder{Format: evon.FormatSystemd}.Encode(n.InnerNodes)
+type Encoder struct {
+	Format Format
+}
+
+type envVariable struct {
+	name  string
+	value string
+}
+
+func (e Encoder) Encode(nodes []*Node) ([]byte, error) {
+	var vars []envVariable
+	walkValuedNodes(nodes, func(node *Node) {
+		vars = append(vars, envVariable{
+			name:  node.Name,
+			value: valueToString(node.Value),
+		})
+	})
+
+	b := &bytes.Buffer{}
+
+	var err error
+	switch e.Format {
+	case FormatEnv:
+		for _, v := range vars {
+			writeEnvLine(b, v.name, v.value)
+		}
+	case FormatComposeMap, FormatComposeList:
+		err = writeCompose(b, vars, e.Format == FormatComposeList)
+	case FormatSystemd:
+		err = writeSystemd(b, vars)
+	case FormatShell:
+		err = writeShell(b, vars)
+	case FormatGitHubEnv:
+		err = writeGitHubEnv(b, vars)
+	default:
+		return nil, fmt.Errorf("unknown format %s", e.Format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error encoding %s: %w", e.Format, err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// writeCompose writes docker-compose environment section.
+// "$" is escaped as "$$" because compose interpolates values
+func writeCompose(b *bytes.Buffer, vars []envVariable, asList bool) error {
+	env := &yaml.Node{Kind: yaml.MappingNode}
+	if asList {
+		env = &yaml.Node{Kind: yaml.SequenceNode}
+	}
+
+	for _, v := range vars {
+		if v.name == "" || strings.Contains(v.name, "=") {
+			return fmt.Errorf("name of node %q can't be used in docker-compose", v.name)
+		}
+
+		value := strings.ReplaceAll(v.value, "$", "$$")
+		if asList {
+			env.Content = append(env.Content, yamlString(v.name+"="+value))
+		} else {
+			env.Content = append(env.Content, yamlString(v.name), yamlString(value))
+		}
+	}
+
+	if len(vars) == 0 {
+		env.Style = yaml.FlowStyle
+	}
+
+	out, err := encodeYAML(yamlMapping(yamlString("environment"), env))
+	if err != nil {
+		return err
+	}
+
+	b.Write(out)
+	return nil
+}
+
+// writeSystemd writes Environment directives.
+// Values are double-quoted with C-like escapes,
+// "%" is escaped as "%%" because systemd expands specifiers
+func writeSystemd(b *bytes.Buffer, vars []envVariable) error {
+	for _, v := range vars {
+		if v.name == "" || strings.ContainsAny(v.name, "= \t\n\r\"'\\%") {
+			return fmt.Errorf("name of node %q can't be used in systemd unit", v.name)
+		}
+
+		b.WriteString(`Environment="`)
+		b.WriteString(v.name)
+		b.WriteByte('=')
+
+		for i := 0; i < len(v.value); i++ {
+			switch c := v.value[i]; c {
+			case '"', '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			case '\t':
+				b.WriteString(`\t`)
+			case '%':
+				b.WriteString("%%")
+			default:
+				if c < 0x20 || c == 0x7f {
+					fmt.Fprintf(b, `\x%02x`, c)
+					continue
+				}
+
+				b.WriteByte(c)
+			}
+		}
+
+		b.WriteString("\"\n")
+	}
+
+	return nil
+}
+
+// writeShell writes export commands with single-quoted values.
+// Names with "-" replaced by "_" must be valid shell identifiers
+// e.g. "HOSTS_[0]" can't be exported
+func writeShell(b *bytes.Buffer, vars []envVariable) error {
+	names := make(map[string]string, len(vars))
+	for _, v := range vars {
+		name := strings.ReplaceAll(v.name, FieldSplitter, "_")
+		if !shellNameRegexp.MatchString(name) {
+			return fmt.Errorf("name of node %q isn't valid shell variable name", v.name)
+		}
+
+		if prev, ok := names[name]; ok {
+			return fmt.Errorf("names of nodes %q and %q are both exported as %s", prev, v.name, name)
+		}
+		names[name] = v.name
+
+		b.WriteString("export ")
+		b.WriteString(name)
+		b.WriteString("='")
+		b.WriteString(strings.ReplaceAll(v.value, "'", `'\''`))
+		b.WriteString("'\n")
+	}
+
+	return nil
+}
+
+// writeGitHubEnv writes every variable as heredoc.
+// Delimiter is suffixed with number if value has line equal to it.
+// Trailing "\r" of lines is ignored, so CRLF values can't end heredoc too
+func writeGitHubEnv(b *bytes.Buffer, vars []envVariable) error {
+	for _, v := range vars {
+		if v.name == "" || strings.ContainsAny(v.name, "=<\n\r") {
+			return fmt.Errorf("name of node %q can't be used in GitHub env", v.name)
+		}
+
+		delimiter := gitHubEnvDelimiter
+		for idx := 1; hasLine(v.value, delimiter); idx++ {
+			delimiter = gitHubEnvDelimiter + "_" + strconv.Itoa(idx)
+		}
+
+		b.WriteString(v.name)
+		b.WriteString("<<")
+		b.WriteString(delimiter)
+		b.WriteByte('\n')
+		b.WriteString(v.value)
+		b.WriteByte('\n')
+		b.WriteString(delimiter)
+		b.WriteByte('\n')
+	}
+
+	return nil
+}
+
+// hasLine reports if s has line equal to line
+func hasLine(s, line string) bool {
+	for _, l := range strings.Split(s, "\n") {
+		if strings.TrimSuffix(l, "\r") == line {
+			return true
+		}
+	}
+
+	return false
+}
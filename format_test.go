@@ -0,0 +1,226 @@
+package evon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Encoder(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		format   Format
+		input    string
+		expected string
+	}
+
+	tests := map[string]testCase{
+		"ENV": {
+			format: FormatEnv,
+			input: `DB_HOST=localhost
+DB_SSL-MODE=disable
+HOSTS_[0]=a
+`,
+			expected: `DB_HOST=localhost
+DB_SSL-MODE=disable
+HOSTS_[0]=a
+`,
+		},
+		"COMPOSE_MAP": {
+			format: FormatComposeMap,
+			input: `DB_HOST=localhost
+DB_PORT=5432
+DB_PASSWORD=pa$$word
+DEBUG=true
+HOSTS_[0]=a: b
+`,
+			expected: `environment:
+  DB_HOST: localhost
+  DB_PORT: "5432"
+  DB_PASSWORD: pa$$$$word
+  DEBUG: "true"
+  HOSTS_[0]: 'a: b'
+`,
+		},
+		"COMPOSE_MAP_EMPTY": {
+			format:   FormatComposeMap,
+			input:    "",
+			expected: "environment: {}\n",
+		},
+		"COMPOSE_LIST": {
+			format: FormatComposeList,
+			input: `DB_HOST=localhost
+DB_PORT=5432
+DB_PASSWORD=$secret
+`,
+			expected: `environment:
+  - DB_HOST=localhost
+  - DB_PORT=5432
+  - DB_PASSWORD=$$secret
+`,
+		},
+		"SYSTEMD": {
+			format: FormatSystemd,
+			input: `DB_HOST=localhost
+DB_SSL-MODE=disable
+GREETING=say "hi" \ 100%
+EMPTY=
+`,
+			expected: `Environment="DB_HOST=localhost"
+Environment="DB_SSL-MODE=disable"
+Environment="GREETING=say \"hi\" \\ 100%%"
+Environment="EMPTY="
+`,
+		},
+		"SHELL": {
+			format: FormatShell,
+			input: `DB_HOST=localhost
+GREETING=it's $HOME
+EMPTY=
+`,
+			expected: `export DB_HOST='localhost'
+export GREETING='it'\''s $HOME'
+export EMPTY=''
+`,
+		},
+		"SHELL_FIELD_SPLITTER": {
+			format: FormatShell,
+			input: `APP-INFO_NAME=evon
+DB_SSL-MODE=disable
+`,
+			expected: `export APP_INFO_NAME='evon'
+export DB_SSL_MODE='disable'
+`,
+		},
+		"GITHUB_ENV": {
+			format: FormatGitHubEnv,
+			input: `DB_HOST=localhost
+DB_SSL-MODE=disable
+`,
+			expected: `DB_HOST<<EOF
+localhost
+EOF
+DB_SSL-MODE<<EOF
+disable
+EOF
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var nodes []*Node
+			if root, ok := ParseToNodes([]byte(tc.input))[""]; ok {
+				nodes = root.InnerNodes
+			}
+
+			actual, err := Encoder{Format: tc.format}.Encode(nodes)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func Test_EncoderMultilineValues(t *testing.T) {
+	t.Parallel()
+
+	nodes := []*Node{
+		{Name: "CERT", Value: "line1\nEOF\r\nline3"},
+		{Name: "NOTE", Value: "tab\there"},
+	}
+
+	type testCase struct {
+		format   Format
+		expected string
+	}
+
+	tests := map[string]testCase{
+		"SYSTEMD": {
+			format: FormatSystemd,
+			expected: `Environment="CERT=line1\nEOF\r\nline3"
+Environment="NOTE=tab\there"
+`,
+		},
+		"GITHUB_ENV": {
+			format:   FormatGitHubEnv,
+			expected: "CERT<<EOF_1\nline1\nEOF\r\nline3\nEOF_1\nNOTE<<EOF\ntab\there\nEOF\n",
+		},
+		"COMPOSE_MAP": {
+			format: FormatComposeMap,
+			expected: `environment:
+  CERT: "line1\nEOF\r\nline3"
+  NOTE: "tab\there"
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := Encoder{Format: tc.format}.Encode(nodes)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func Test_EncoderErrors(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		format Format
+		input  string
+		err    string
+	}
+
+	tests := map[string]testCase{
+		"SHELL_FIELD_SPLITTER_COLLISION": {
+			format: FormatShell,
+			input:  "DB_SSL-MODE=disable\nDB_SSL_MODE=require",
+			err:    `error encoding shell: names of nodes "DB_SSL-MODE" and "DB_SSL_MODE" are both exported as DB_SSL_MODE`,
+		},
+		"SHELL_SLICE_ELEMENT": {
+			format: FormatShell,
+			input:  "HOSTS_[0]=a",
+			err:    `error encoding shell: name of node "HOSTS_[0]" isn't valid shell variable name`,
+		},
+		"SYSTEMD_SPACE": {
+			format: FormatSystemd,
+			input:  "MY KEY=a",
+			err:    `error encoding systemd: name of node "MY KEY" can't be used in systemd unit`,
+		},
+		"UNKNOWN_FORMAT": {
+			format: Format(42),
+			input:  "A=b",
+			err:    "unknown format Format(42)",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ns := ParseToNodes([]byte(tc.input))
+
+			_, err := Encoder{Format: tc.format}.Encode(ns[""].InnerNodes)
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func Test_ParseFormat(t *testing.T) {
+	t.Parallel()
+
+	for f := FormatEnv; f <= FormatGitHubEnv; f++ {
+		parsed, err := ParseFormat(f.String())
+		require.NoError(t, err)
+		require.Equal(t, f, parsed)
+	}
+
+	_, err := ParseFormat("xml")
+	require.EqualError(t, err, `unknown format "xml"`)
+}
@@ -0,0 +1,235 @@
+package evon
+
+import (
+	"testing"
+)
+
+func addSeedCorpus(f *testing.F) {
+	for _, seed := range [][]byte{
+		fullObjectDotEnv,
+		prefixedExpectedDotEnv,
+		simpleObjectDotEnv,
+		matreshkaDotEnv,
+		complexYamlConfig,
+		nil,
+		[]byte("A=1"),
+		[]byte("A=1\nA_B=2\n"),
+		[]byte("# comment\n\nA_[0]_B=1\r\nA_[1]_B=2\n"),
+		[]byte("=\n_=\nA_=1\n"),
+		[]byte("DSN=postgres://u:p@host/db?sslmode=disable"),
+	} {
+		f.Add(seed)
+	}
+}
+
+func FuzzParseToNodes(f *testing.F) {
+	addSeedCorpus(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ns := ParseToNodes(data)
+
+		for key, n := range ns {
+			if n.Name != key {
+				t.Fatalf("node %q is stored under %q", n.Name, key)
+			}
+		}
+
+		root := ns[""]
+		if root == nil {
+			if len(ns) != 0 {
+				t.Fatalf("storage without root has %d nodes", len(ns))
+			}
+			return
+		}
+
+		// every node of tree is stored under its name
+		// and every stored node is in tree
+		reachable := map[string]*Node{}
+		var walk func(n *Node)
+		walk = func(n *Node) {
+			if prev, ok := reachable[n.Name]; ok && prev != n {
+				t.Fatalf("node %q is duplicated in tree", n.Name)
+			}
+			reachable[n.Name] = n
+
+			for _, inner := range n.InnerNodes {
+				if !isParentName(n.Name, inner.Name) && n != root {
+					t.Fatalf("node %q is inside %q", inner.Name, n.Name)
+				}
+				walk(inner)
+			}
+		}
+		walk(root)
+
+		if len(reachable) != len(ns) {
+			t.Fatalf("tree has %d nodes, storage has %d", len(reachable), len(ns))
+		}
+
+		rebuilt := NodesToStorage(root)
+		if len(rebuilt) != len(ns) {
+			t.Fatalf("NodesToStorage has %d nodes, storage has %d", len(rebuilt), len(ns))
+		}
+
+		for key, n := range ns {
+			if rebuilt[key] != n {
+				t.Fatalf("NodesToStorage has different node under %q", key)
+			}
+		}
+	})
+}
+
+func FuzzUnmarshalMap(f *testing.F) {
+	addSeedCorpus(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		dst := map[string]any{}
+		_ = Unmarshal(data, dst)
+	})
+}
+
+func FuzzMarshalRoundTrip(f *testing.F) {
+	addSeedCorpus(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ns := ParseToNodes(data)
+
+		root := ns[""]
+		if root == nil {
+			return
+		}
+
+		marshalled := Marshal(root.InnerNodes)
+		again := ParseToNodes(marshalled)
+
+		expected := storageValues(ns)
+		actual := storageValues(again)
+
+		if len(expected) != len(actual) {
+			t.Fatalf("round trip changed number of values from %d to %d:\n%s", len(expected), len(actual), marshalled)
+		}
+
+		for key, v := range expected {
+			if actual[key] != v {
+				t.Fatalf("round trip changed %q from %q to %q", key, v, actual[key])
+			}
+		}
+
+		if string(Marshal(again[""].InnerNodes)) != string(marshalled) {
+			t.Fatalf("marshalling is not stable:\n%s", marshalled)
+		}
+	})
+}
+
+func storageValues(ns NodeStorage) map[string]string {
+	out := map[string]string{}
+	for key, n := range ns {
+		if n.Value != nil {
+			out[key] = valueToString(n.Value)
+		}
+	}
+
+	return out
+}
+
+// FuzzFromTOML checks that malformed toml returns error instead of panic
+// and names of inner nodes are built from names of their parents
+func FuzzFromTOML(f *testing.F) {
+	for _, seed := range []string{
+		"a = 1\n[b]\nc = 'd'\n",
+		"[[a]]\nb = 1979-05-27T07:32:00.999+07:00\n[a.c]\nd = '''x'''\n",
+		"a = { b = [1, 2.5e3, -inf], c.d = true }\n",
+		"a = ",
+		"[a",
+		"a = \"\\u00",
+		"a = [1,",
+		"[[a]]\n[a]\n",
+		"a.b = 1\na = 2\n",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		root, err := FromTOML(data)
+		if err != nil {
+			return
+		}
+
+		var check func(n *Node)
+		check = func(n *Node) {
+			for _, inner := range n.InnerNodes {
+				if n.Name != "" && !isParentName(n.Name, inner.Name) {
+					t.Fatalf("name of node %q isn't prefixed with name of parent %q", inner.Name, n.Name)
+				}
+
+				check(inner)
+			}
+		}
+
+		check(root)
+	})
+}
+
+func FuzzTOMLRoundTrip(f *testing.F) {
+	for _, seed := range []string{
+		"a = 1\n[b]\nc = 'd'\n",
+		"a = [1, [2, 3], { b = \"c\" }]\n",
+		"[[a]]\nb = 1979-05-27 07:32:00Z\n[a.c]\nd = \"\"\"\nx\\\n  y\"\"\"\n",
+		"a.'b'.\"c\" = 0x1F\n",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		root, err := FromTOML(data)
+		if err != nil {
+			return
+		}
+
+		encoded, err := ToTOML(root)
+		if err != nil {
+			return
+		}
+
+		again, err := FromTOML(encoded)
+		if err != nil {
+			t.Fatalf("error parsing encoded toml: %v\n%s", err, encoded)
+		}
+
+		d := Diff(root, again)
+		if !d.IsEmpty() {
+			t.Fatalf("round trip changed values %+v:\n%s", d, encoded)
+		}
+	})
+}
+
+func FuzzPropertiesRoundTrip(f *testing.F) {
+	for _, seed := range []string{
+		"a.b = 1\nc:d\ne f\n",
+		"a[0].b=\\u00e9\\ud83d\\ude00\n! comment\n",
+		"key\\ with\\=sep = \\  value \\\n  continued\n",
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		root, err := FromProperties(data)
+		if err != nil {
+			return
+		}
+
+		encoded, err := ToProperties(root)
+		if err != nil {
+			return
+		}
+
+		again, err := FromProperties(encoded)
+		if err != nil {
+			t.Fatalf("error parsing encoded properties: %v\n%s", err, encoded)
+		}
+
+		d := Diff(root, again)
+		if !d.IsEmpty() {
+			t.Fatalf("round trip changed values %+v:\n%s", d, encoded)
+		}
+	})
+}
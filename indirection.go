@@ -0,0 +1,156 @@
+package evon
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrFileNotAllowed = errors.New("file is outside of allowed directories")
+)
+
+// resolveFileIndirection reads values for variables with file suffix
+// and returns them by plain variable name.
+// Only variables which plain names are accepted (i.e. mapped into destination) are read
+func resolveFileIndirection(srcNodes NodeStorage, o unmarshalOpts, accept func(plainKey string) bool) (map[string]*Node, error) {
+	out := map[string]*Node{}
+
+	for key, n := range srcNodes {
+		if n.Value == nil || !strings.HasSuffix(key, o.fileSuffix) {
+			continue
+		}
+
+		plainKey := strings.TrimSuffix(key, o.fileSuffix)
+		if plainKey == "" || !accept(plainKey) {
+			continue
+		}
+
+		plain, ok := srcNodes[plainKey]
+		if ok && plain.Value != nil {
+			continue
+		}
+
+		path := valueToString(n.Value)
+		if path == "" {
+			continue
+		}
+
+		value, err := readIndirectFile(path, o.fileAllowedDirs, o.fileMaxSize)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", key, err)
+		}
+
+		out[plainKey] = &Node{
+			Name:  plainKey,
+			Value: value,
+		}
+	}
+
+	return out, nil
+}
+
+// fileLookup returns function that reads value of variable
+// from file set in variable with file suffix.
+// Only referenced variables are read
+func fileLookup(ns NodeStorage, o unmarshalOpts) func(name string) (string, bool, error) {
+	return func(name string) (string, bool, error) {
+		n, ok := ns[name+o.fileSuffix]
+		if !ok || n.Value == nil {
+			return "", false, nil
+		}
+
+		path := valueToString(n.Value)
+		if path == "" {
+			return "", false, nil
+		}
+
+		value, err := readIndirectFile(path, o.fileAllowedDirs, o.fileMaxSize)
+		if err != nil {
+			return "", false, fmt.Errorf("error reading %s: %w", name+o.fileSuffix, err)
+		}
+
+		return value, true, nil
+	}
+}
+
+// withFileNodes returns copy of storage with values read from files.
+// Values are put into copy of tree, so custom unmarshalers
+// of structs get them among inner nodes
+func withFileNodes(ns NodeStorage, fromFiles map[string]*Node) NodeStorage {
+	out := NodeStorage{}
+
+	var index func(n *Node)
+	index = func(n *Node) {
+		out[n.Name] = n
+		for _, inner := range n.InnerNodes {
+			index(inner)
+		}
+	}
+
+	if root := ns[""]; root != nil {
+		index(root.Clone())
+	}
+
+	keys := make([]string, 0, len(fromFiles))
+	for key := range fromFiles {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		out.AddNode(&Node{
+			Name:  key,
+			Value: fromFiles[key].Value,
+		})
+	}
+
+	return out
+}
+
+func readIndirectFile(path string, allowedDirs []string, maxSize int64) (string, error) {
+	if len(allowedDirs) != 0 {
+		resolvedPath, err := realPath(path)
+		if err != nil {
+			return "", fmt.Errorf("error resolving path %s: %w", path, err)
+		}
+
+		allowed := false
+		for _, dir := range allowedDirs {
+			realDir, err := realPath(dir)
+			if err != nil {
+				continue
+			}
+
+			rel, err := filepath.Rel(realDir, resolvedPath)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return "", fmt.Errorf("%w: %s", ErrFileNotAllowed, path)
+		}
+
+		path = resolvedPath
+	}
+
+	b, err := readFileLimited(path, maxSize)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+func realPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.EvalSymlinks(abs)
+}
@@ -0,0 +1,116 @@
+package evon
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type indirectionTestConfig struct {
+	DB struct {
+		User string `env:"USER"`
+		Pwd  string `env:"PWD"`
+	} `env:"DB"`
+}
+
+func TestFileIndirection(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "db")
+	writeTestFile(t, secretPath, "secret\n")
+
+	t.Run("default_suffix", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg indirectionTestConfig
+		err := Unmarshal([]byte(`DB_USER=admin
+DB_PWD_FILE=`+secretPath), &cfg, WithFileIndirection(""))
+		require.NoError(t, err)
+		require.Equal(t, "admin", cfg.DB.User)
+		require.Equal(t, "secret", cfg.DB.Pwd)
+	})
+
+	t.Run("custom_suffix", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg indirectionTestConfig
+		err := Unmarshal([]byte(`DB_PWD-PATH=`+secretPath), &cfg, WithFileIndirection("-PATH"))
+		require.NoError(t, err)
+		require.Equal(t, "secret", cfg.DB.Pwd)
+	})
+
+	t.Run("plain_value_wins", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg indirectionTestConfig
+		err := Unmarshal([]byte(`DB_PWD=plain
+DB_PWD_FILE=`+secretPath), &cfg, WithFileIndirection(""))
+		require.NoError(t, err)
+		require.Equal(t, "plain", cfg.DB.Pwd)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg indirectionTestConfig
+		err := Unmarshal([]byte(`DB_PWD_FILE=`+secretPath), &cfg)
+		require.NoError(t, err)
+		require.Empty(t, cfg.DB.Pwd)
+	})
+
+	t.Run("allowed_dir", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg indirectionTestConfig
+		err := Unmarshal([]byte(`DB_PWD_FILE=`+secretPath), &cfg, WithFileIndirection("", dir))
+		require.NoError(t, err)
+		require.Equal(t, "secret", cfg.DB.Pwd)
+	})
+
+	t.Run("not_allowed_dir", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg indirectionTestConfig
+		err := Unmarshal([]byte(`DB_PWD_FILE=`+secretPath), &cfg, WithFileIndirection("", t.TempDir()))
+		require.ErrorIs(t, err, ErrFileNotAllowed)
+	})
+
+	t.Run("symlink_escape", func(t *testing.T) {
+		t.Parallel()
+
+		allowed := t.TempDir()
+		link := filepath.Join(allowed, "db")
+		require.NoError(t, os.Symlink(secretPath, link))
+
+		var cfg indirectionTestConfig
+		err := Unmarshal([]byte(`DB_PWD_FILE=`+link), &cfg, WithFileIndirection("", allowed))
+		require.ErrorIs(t, err, ErrFileNotAllowed)
+	})
+	t.Run("unrelated_keys", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg indirectionTestConfig
+		err := UnmarshalWithNodesAndPrefix("APP", ParseToNodes([]byte(`APP_DB_PWD_FILE=`+secretPath+`
+LOG_FILE=/nonexistent/log
+APP_LOG_FILE=/nonexistent/log`)), &cfg, WithFileIndirection("_FILE"))
+		require.NoError(t, err)
+		require.Equal(t, "secret", cfg.DB.Pwd)
+	})
+
+	t.Run("max_size", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg indirectionTestConfig
+		err := Unmarshal([]byte(`DB_PWD_FILE=`+secretPath), &cfg,
+			WithFileIndirection(""), WithFileIndirectionMaxSize(3))
+		require.ErrorIs(t, err, ErrFileTooLarge)
+
+		err = Unmarshal([]byte(`DB_PWD_FILE=`+secretPath), &cfg,
+			WithFileIndirection(""), WithFileIndirectionMaxSize(7))
+		require.NoError(t, err)
+		require.Equal(t, "secret", cfg.DB.Pwd)
+	})
+}
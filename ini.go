@@ -0,0 +1,252 @@
+package evon
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FromINI converts ini file into tree of nodes.
+// Section names are split by "." into nested nodes,
+// "key[i]" becomes "KEY_[i]" node and "key[]" appends next element.
+// Keys are upper-cased and "_" in them is replaced with FieldSplitter.
+// Lines starting with ";" or "#" are comments,
+// values in double quotes are unquoted
+// e.g.
+//
+//	[storage_config.s3]
+//	endpoint = minio:9000
+//	buckets[] = loki
+//	buckets[] = tempo
+//
+// is converted into
+//
+//	STORAGE-CONFIG_S3_ENDPOINT=minio:9000
+//	STORAGE-CONFIG_S3_BUCKETS_[0]=loki
+//	STORAGE-CONFIG_S3_BUCKETS_[1]=tempo
+func FromINI(data []byte) (*Node, error) {
+	root := &Node{}
+	section := root
+
+	for idx, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+
+		var err error
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("error parsing ini at line %d: section header must end with ']'", idx+1)
+			}
+
+			section = root
+			for _, part := range strings.Split(line[1:len(line)-1], ".") {
+				section, err = resolveIndexedKey(section, strings.TrimSpace(part))
+				if err != nil {
+					return nil, fmt.Errorf("error parsing ini at line %d: %w", idx+1, err)
+				}
+			}
+
+			continue
+		}
+
+		sep := strings.IndexAny(line, "=:")
+		if sep == -1 {
+			return nil, fmt.Errorf("error parsing ini at line %d: expected key = value", idx+1)
+		}
+
+		n, err := resolveIndexedKey(section, strings.TrimSpace(line[:sep]))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing ini at line %d: %w", idx+1, err)
+		}
+
+		n.Value = unquoteINIValue(strings.TrimSpace(line[sep+1:]))
+	}
+
+	return root, nil
+}
+
+// resolveIndexedKey returns node by key with optional index suffixes
+// e.g. "hosts[0]", "hosts[]", "matrix[0][1]".
+// Parts of ini sections and keys and parts of dotted properties keys
+// are resolved by it, so both formats accept the same indexes
+func resolveIndexedKey(parent *Node, key string) (*Node, error) {
+	name, indexes, _ := strings.Cut(key, "[")
+	if name == "" {
+		return nil, fmt.Errorf("empty key %q", key)
+	}
+
+	n := findOrAddNode(parent, joinPath(parent.Name, strings.ToUpper(nameToEvonName(name))))
+
+	if indexes == "" {
+		return n, nil
+	}
+
+	if !strings.HasSuffix(indexes, "]") {
+		return nil, fmt.Errorf("invalid index in key %q", key)
+	}
+
+	for _, idx := range strings.Split(strings.TrimSuffix("["+indexes, "]"), "]") {
+		if idx == "[" {
+			n = appendElement(n)
+			continue
+		}
+
+		_, ok := parseSliceIndex(idx + "]")
+		if !ok {
+			return nil, fmt.Errorf("invalid index in key %q", key)
+		}
+
+		n = findOrAddNode(n, joinPath(n.Name, idx+"]"))
+	}
+
+	return n, nil
+}
+
+// findOrAddNode returns inner node of parent by name
+// or appends new one if there is no such node
+func findOrAddNode(parent *Node, name string) *Node {
+	n := findInnerNode(parent, name)
+	if n == nil {
+		n = &Node{Name: name}
+		parent.InnerNodes = append(parent.InnerNodes, n)
+	}
+
+	return n
+}
+
+func unquoteINIValue(v string) string {
+	if len(v) < 2 || v[0] != '"' || v[len(v)-1] != '"' {
+		return v
+	}
+
+	unquoted, err := strconv.Unquote(v)
+	if err != nil {
+		return v
+	}
+
+	return unquoted
+}
+
+// ToINI converts tree of nodes into ini file.
+// Names are lower-cased and FieldSplitter in them is replaced with "_".
+// Nodes with inner nodes become sections, "_[i]" nodes become "key[i]" keys
+// or sections "key[i]" if element has inner nodes.
+// Values with leading or trailing spaces, quotes or line breaks are quoted.
+// Nodes without value are skipped
+func ToINI(n *Node) ([]byte, error) {
+	b := &bytes.Buffer{}
+
+	err := writeINISection(b, n, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func writeINISection(b *bytes.Buffer, n *Node, section string) error {
+	type subsection struct {
+		node *Node
+		name string
+	}
+
+	var (
+		lines       []string
+		subsections []subsection
+	)
+
+	var collect func(n *Node, key string) error
+	collect = func(n *Node, key string) error {
+		if len(n.InnerNodes) == 0 {
+			if n.Value != nil {
+				lines = append(lines, key+" = "+iniValue(n.Value))
+			}
+
+			return nil
+		}
+
+		if n.Value != nil {
+			return fmt.Errorf("node %s has both value and inner nodes and can't be converted to ini", n.Name)
+		}
+
+		elems, isSlice := sliceElements(n)
+		if !isSlice {
+			name := key
+			if section != "" {
+				name = section + "." + key
+			}
+
+			subsections = append(subsections, subsection{node: n, name: name})
+			return nil
+		}
+
+		for idx, e := range elems {
+			err := collect(e, key+"["+strconv.Itoa(idx)+"]")
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	for _, child := range n.InnerNodes {
+		key := fieldNameToKey(relativeChildName(n.Name, child.Name))
+		if !isValidINIKey(key) {
+			return fmt.Errorf("name of node %s can't be converted to ini key", child.Name)
+		}
+
+		err := collect(child, key)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(lines) > 0 {
+		if section != "" {
+			if b.Len() > 0 {
+				b.WriteByte('\n')
+			}
+
+			b.WriteString("[" + section + "]\n")
+		}
+
+		for _, line := range lines {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+
+	for _, sub := range subsections {
+		err := writeINISection(b, sub.node, sub.name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func isValidINIKey(key string) bool {
+	if key == "" || key != strings.TrimSpace(key) {
+		return false
+	}
+
+	if key[0] == ';' || key[0] == '#' {
+		return false
+	}
+
+	return !strings.ContainsAny(key, "=:.[]\n\r")
+}
+
+func iniValue(v any) string {
+	s := valueToString(v)
+	if s != strings.TrimSpace(s) || strings.HasPrefix(s, `"`) || strings.ContainsAny(s, "\n\r") {
+		return strconv.Quote(s)
+	}
+
+	return s
+}
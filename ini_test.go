@@ -0,0 +1,144 @@
+package evon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromINI(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected string
+	}
+
+	tests := map[string]testCase{
+		"EMPTY": {
+			input:    "; only comment\n",
+			expected: "",
+		},
+		"SECTIONS": {
+			input: `
+title = loki
+# comment
+[server]
+http_listen_port = 3100
+name: "  padded  "
+
+[storage_config.s3]
+endpoint = minio:9000
+`,
+			expected: `TITLE=loki
+SERVER_HTTP-LISTEN-PORT=3100
+SERVER_NAME=  padded  
+STORAGE-CONFIG_S3_ENDPOINT=minio:9000
+`,
+		},
+		"ARRAYS": {
+			input: `
+peers[] = a
+peers[] = b
+matrix[0][1] = 2
+[configs[0].index]
+period = 24h
+`,
+			expected: `PEERS_[0]=a
+PEERS_[1]=b
+MATRIX_[0]_[1]=2
+CONFIGS_[0]_INDEX_PERIOD=24h
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root, err := FromINI([]byte(tc.input))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(Marshal(root.InnerNodes)))
+		})
+	}
+}
+
+func Test_FromINIError(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input string
+		err   string
+	}
+
+	tests := map[string]testCase{
+		"NO_SEPARATOR": {
+			input: "a = 1\nb\n",
+			err:   "error parsing ini at line 2: expected key = value",
+		},
+		"UNCLOSED_SECTION": {
+			input: "[a",
+			err:   "error parsing ini at line 1: section header must end with ']'",
+		},
+		"EMPTY_KEY": {
+			input: "= 1",
+			err:   `error parsing ini at line 1: empty key ""`,
+		},
+		"INVALID_INDEX": {
+			input: "a[b] = 1",
+			err:   `error parsing ini at line 1: invalid index in key "a[b]"`,
+		},
+		"UNCLOSED_INDEX": {
+			input: "a[0 = 1",
+			err:   `error parsing ini at line 1: invalid index in key "a[0"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := FromINI([]byte(tc.input))
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func Test_ToINI(t *testing.T) {
+	t.Parallel()
+
+	ns := ParseToNodes([]byte(`
+AUTH-ENABLED=false
+SERVER_HTTP-LISTEN-PORT=3100
+SERVER_NAME= padded
+SERVER_/{GRPC}_PORT=9095
+PEERS_[1]=b
+PEERS_[0]=a
+CONFIGS_[0]_FROM=2020-10-24
+CONFIGS_[0]_INDEX_PERIOD=24h
+`))
+
+	actual, err := ToINI(ns[""])
+	require.NoError(t, err)
+	require.Equal(t, `auth_enabled = false
+peers[0] = a
+peers[1] = b
+
+[server]
+http_listen_port = 3100
+name = " padded"
+
+[server./{grpc}]
+port = 9095
+
+[configs[0]]
+from = 2020-10-24
+
+[configs[0].index]
+period = 24h
+`, string(actual))
+
+	ns = ParseToNodes([]byte("A.B=1\n"))
+	_, err = ToINI(ns[""])
+	require.ErrorContains(t, err, "name of node A.B can't be converted to ini key")
+}
@@ -0,0 +1,70 @@
+// Package gentest holds structs for comparing
+// code generated with evon-gen against reflective (un)marshalling
+package gentest
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+//go:generate go run ../../cmd/evon-gen
+
+type Mode string
+
+// Level is stored in lower case
+type Level string
+
+func (l *Level) UnmarshalText(text []byte) error {
+	*l = Level(strings.ToLower(string(text)))
+	return nil
+}
+
+//evon:generate
+type Config struct {
+	AppInfo  AppInfo   `evon:"APP-INFO"`
+	Postgres *Postgres `evon:"POSTGRES"`
+	Servers  []Server  `evon:"SERVERS"`
+	Ports    []uint16  `evon:"PORTS"`
+	Mode     Mode      `evon:"MODE,omitempty"`
+	LogLevel Level     `env:"LOG-LEVEL"`
+	Debug    bool
+	Ratio    float64        `evon:"RATIO,omitempty,max=1"`
+	Timeout  *time.Duration `evon:"TIMEOUT"`
+	Internal string         `evon:"-"`
+	Redis    struct {
+		Host string
+		Db   int8
+	}
+}
+
+type AppInfo struct {
+	Name            string
+	Version         string
+	StartupDuration time.Duration
+	StartedAt       time.Time `evon:"STARTED-AT,omitempty"`
+}
+
+//evon:generate
+type Postgres struct {
+	Host    string `evon:"HOST"`
+	Port    uint16 `evon:"PORT"`
+	SslMode string `evon:"SSL-MODE,omitempty"`
+	// Password is marshalled into node with Secret flag
+	Password string `evon:"PASSWORD,omitempty,secret"`
+}
+
+func (p *Postgres) ValidateEnv() error {
+	if p.SslMode != "" && p.Host == "" {
+		return errors.New("ssl mode is set without host")
+	}
+
+	return nil
+}
+
+type Server struct {
+	Name  string   `evon:"NAME,required"`
+	Port  int      `evon:"PORT"`
+	Tags  []string `evon:"TAGS"`
+	Proxy *Postgres
+}
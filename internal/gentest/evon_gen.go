@@ -0,0 +1,564 @@
+// Code generated by evon-gen. DO NOT EDIT.
+
+package gentest
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.redsock.ru/evon"
+)
+
+// MarshalEnv returns nodes of Config's fields under prefix
+func (v *Config) MarshalEnv(prefix string) ([]*evon.Node, error) {
+	prefix = strings.ToUpper(prefix)
+	if prefix != "" {
+		prefix += evon.ObjectSplitter
+	}
+
+	out := make([]*evon.Node, 0, 11)
+	n1 := &evon.Node{Name: prefix + "APP-INFO"}
+	n1.InnerNodes = append(n1.InnerNodes, &evon.Node{Name: prefix + "APP-INFO_NAME", Value: v.AppInfo.Name})
+	n1.InnerNodes = append(n1.InnerNodes, &evon.Node{Name: prefix + "APP-INFO_VERSION", Value: v.AppInfo.Version})
+	n1.InnerNodes = append(n1.InnerNodes, &evon.Node{Name: prefix + "APP-INFO_STARTUP-DURATION", Value: v.AppInfo.StartupDuration})
+	if v.AppInfo.StartedAt != (time.Time{}) {
+		n1.InnerNodes = append(n1.InnerNodes, &evon.Node{Name: prefix + "APP-INFO_STARTED-AT", Value: evonFormatTime(v.AppInfo.StartedAt)})
+	}
+	out = append(out, n1)
+	if v.Postgres != nil {
+		n2 := &evon.Node{Name: prefix + "POSTGRES"}
+		n2.InnerNodes = append(n2.InnerNodes, &evon.Node{Name: prefix + "POSTGRES_HOST", Value: v.Postgres.Host})
+		n2.InnerNodes = append(n2.InnerNodes, &evon.Node{Name: prefix + "POSTGRES_PORT", Value: v.Postgres.Port})
+		if v.Postgres.SslMode != "" {
+			n2.InnerNodes = append(n2.InnerNodes, &evon.Node{Name: prefix + "POSTGRES_SSL-MODE", Value: v.Postgres.SslMode})
+		}
+		if v.Postgres.Password != "" {
+			n2.InnerNodes = append(n2.InnerNodes, &evon.Node{Name: prefix + "POSTGRES_PASSWORD", Value: v.Postgres.Password, Secret: true})
+		}
+		out = append(out, n2)
+	}
+	if len(v.Servers) != 0 {
+		n3 := &evon.Node{Name: prefix + "SERVERS"}
+		for i4 := range v.Servers {
+			e5 := &evon.Node{Name: n3.Name + "_[" + strconv.Itoa(i4) + "]"}
+			p6 := e5.Name + evon.ObjectSplitter
+			e5.InnerNodes = append(e5.InnerNodes, &evon.Node{Name: p6 + "NAME", Value: v.Servers[i4].Name})
+			e5.InnerNodes = append(e5.InnerNodes, &evon.Node{Name: p6 + "PORT", Value: v.Servers[i4].Port})
+			if len(v.Servers[i4].Tags) != 0 {
+				e5.InnerNodes = append(e5.InnerNodes, &evon.Node{Name: p6 + "TAGS", Value: evonFormatSlice(v.Servers[i4].Tags)})
+			}
+			if v.Servers[i4].Proxy != nil {
+				n7 := &evon.Node{Name: p6 + "PROXY"}
+				n7.InnerNodes = append(n7.InnerNodes, &evon.Node{Name: p6 + "PROXY_HOST", Value: v.Servers[i4].Proxy.Host})
+				n7.InnerNodes = append(n7.InnerNodes, &evon.Node{Name: p6 + "PROXY_PORT", Value: v.Servers[i4].Proxy.Port})
+				if v.Servers[i4].Proxy.SslMode != "" {
+					n7.InnerNodes = append(n7.InnerNodes, &evon.Node{Name: p6 + "PROXY_SSL-MODE", Value: v.Servers[i4].Proxy.SslMode})
+				}
+				if v.Servers[i4].Proxy.Password != "" {
+					n7.InnerNodes = append(n7.InnerNodes, &evon.Node{Name: p6 + "PROXY_PASSWORD", Value: v.Servers[i4].Proxy.Password, Secret: true})
+				}
+				e5.InnerNodes = append(e5.InnerNodes, n7)
+			}
+			n3.InnerNodes = append(n3.InnerNodes, e5)
+		}
+		out = append(out, n3)
+	}
+	if len(v.Ports) != 0 {
+		out = append(out, &evon.Node{Name: prefix + "PORTS", Value: evonFormatSlice(v.Ports)})
+	}
+	if v.Mode != "" {
+		out = append(out, &evon.Node{Name: prefix + "MODE", Value: v.Mode})
+	}
+	out = append(out, &evon.Node{Name: prefix + "LOG-LEVEL", Value: v.LogLevel})
+	out = append(out, &evon.Node{Name: prefix + "DEBUG", Value: v.Debug})
+	if v.Ratio != 0 {
+		out = append(out, &evon.Node{Name: prefix + "RATIO", Value: v.Ratio})
+	}
+	if v.Timeout != nil {
+		out = append(out, &evon.Node{Name: prefix + "TIMEOUT", Value: *v.Timeout})
+	}
+	n8 := &evon.Node{Name: prefix + "REDIS"}
+	n8.InnerNodes = append(n8.InnerNodes, &evon.Node{Name: prefix + "REDIS_HOST", Value: v.Redis.Host})
+	n8.InnerNodes = append(n8.InnerNodes, &evon.Node{Name: prefix + "REDIS_DB", Value: v.Redis.Db})
+	out = append(out, n8)
+	return out, nil
+}
+
+// UnmarshalEnv sets Config's fields from inner nodes of n
+// and validates result with evon.Validate
+func (v *Config) UnmarshalEnv(n *evon.Node) error {
+	var errs1 []error
+	evonWalkNodes(n, func(name2 string, node3 *evon.Node) {
+		var err error
+		switch name2 {
+		case "APP-INFO_NAME":
+			err = evonParseString(node3, &v.AppInfo.Name)
+		case "APP-INFO_VERSION":
+			err = evonParseString(node3, &v.AppInfo.Version)
+		case "APP-INFO_STARTUP-DURATION":
+			err = evonParseDuration(node3, &v.AppInfo.StartupDuration)
+		case "APP-INFO_STARTED-AT":
+			err = evonParseTime(node3, &v.AppInfo.StartedAt)
+		case "POSTGRES_HOST":
+			if v.Postgres != nil {
+				err = evonParseString(node3, &v.Postgres.Host)
+			}
+		case "POSTGRES_PORT":
+			if v.Postgres != nil {
+				err = evonParseUint(node3, &v.Postgres.Port)
+			}
+		case "POSTGRES_SSL-MODE":
+			if v.Postgres != nil {
+				err = evonParseString(node3, &v.Postgres.SslMode)
+			}
+		case "POSTGRES_PASSWORD":
+			if v.Postgres != nil {
+				err = evonParseString(node3, &v.Postgres.Password)
+			}
+		case "SERVERS":
+			err = evonParseSlice(node3, &v.Servers, func(n *evon.Node, elem *Server) error {
+				var errs5 []error
+				var present8 [1]bool
+				evonWalkNodes(n, func(name6 string, node7 *evon.Node) {
+					var err error
+					switch name6 {
+					case "NAME":
+						err = evonParseString(node7, &elem.Name)
+					case "PORT":
+						err = evonParseInt(node7, &elem.Port)
+					case "TAGS":
+						err = evonParseSlice(node7, &elem.Tags, evonParseString[string])
+					case "PROXY_HOST":
+						if elem.Proxy != nil {
+							err = evonParseString(node7, &elem.Proxy.Host)
+						}
+					case "PROXY_PORT":
+						if elem.Proxy != nil {
+							err = evonParseUint(node7, &elem.Proxy.Port)
+						}
+					case "PROXY_SSL-MODE":
+						if elem.Proxy != nil {
+							err = evonParseString(node7, &elem.Proxy.SslMode)
+						}
+					case "PROXY_PASSWORD":
+						if elem.Proxy != nil {
+							err = evonParseString(node7, &elem.Proxy.Password)
+						}
+					}
+					if err != nil {
+						errs5 = append(errs5, err)
+					}
+					if node7.Value != nil || len(node7.InnerNodes) != 0 {
+						switch name6 {
+						case "NAME":
+							present8[0] = true
+						}
+					}
+				})
+				for i9, r10 := range [...]string{"NAME"} {
+					if !present8[i9] {
+						errs5 = append(errs5, &evon.MissingRequiredError{Path: evonJoinPath(n, r10)})
+					}
+				}
+				return evonErrorOrNil(errs5)
+			})
+		case "PORTS":
+			err = evonParseSlice(node3, &v.Ports, evonParseUint[uint16])
+		case "MODE":
+			err = evonParseString(node3, &v.Mode)
+		case "LOG-LEVEL":
+			err = evonParseText(node3, &v.LogLevel)
+		case "DEBUG":
+			err = evonParseBool(node3, &v.Debug)
+		case "RATIO":
+			err = evonParseFloat(node3, &v.Ratio)
+		case "TIMEOUT":
+			if v.Timeout != nil {
+				err = evonParseDuration(node3, v.Timeout)
+			}
+		case "REDIS_HOST":
+			err = evonParseString(node3, &v.Redis.Host)
+		case "REDIS_DB":
+			err = evonParseInt(node3, &v.Redis.Db)
+		}
+		if err != nil {
+			errs1 = append(errs1, err)
+		}
+	})
+	if len(errs1) != 0 {
+		return evonErrorOrNil(errs1)
+	}
+	return evon.Validate(n.Name, v)
+}
+
+// MarshalEnv returns nodes of Postgres's fields under prefix
+func (v *Postgres) MarshalEnv(prefix string) ([]*evon.Node, error) {
+	prefix = strings.ToUpper(prefix)
+	if prefix != "" {
+		prefix += evon.ObjectSplitter
+	}
+
+	out := make([]*evon.Node, 0, 4)
+	out = append(out, &evon.Node{Name: prefix + "HOST", Value: v.Host})
+	out = append(out, &evon.Node{Name: prefix + "PORT", Value: v.Port})
+	if v.SslMode != "" {
+		out = append(out, &evon.Node{Name: prefix + "SSL-MODE", Value: v.SslMode})
+	}
+	if v.Password != "" {
+		out = append(out, &evon.Node{Name: prefix + "PASSWORD", Value: v.Password, Secret: true})
+	}
+	return out, nil
+}
+
+// UnmarshalEnv sets Postgres's fields from inner nodes of n
+// and validates result with evon.Validate
+func (v *Postgres) UnmarshalEnv(n *evon.Node) error {
+	var errs1 []error
+	evonWalkNodes(n, func(name2 string, node3 *evon.Node) {
+		var err error
+		switch name2 {
+		case "HOST":
+			err = evonParseString(node3, &v.Host)
+		case "PORT":
+			err = evonParseUint(node3, &v.Port)
+		case "SSL-MODE":
+			err = evonParseString(node3, &v.SslMode)
+		case "PASSWORD":
+			err = evonParseString(node3, &v.Password)
+		}
+		if err != nil {
+			errs1 = append(errs1, err)
+		}
+	})
+	if len(errs1) != 0 {
+		return evonErrorOrNil(errs1)
+	}
+	return evon.Validate(n.Name, v)
+}
+
+var evonErrOverflow = errors.New("value out of range")
+
+// evonWalkNodes calls f for every inner node of root
+// with node's name relative to root's name
+// e.g. for root "APP" node "APP_DB_PORT" is passed as "DB_PORT"
+func evonWalkNodes(root *evon.Node, f func(name string, n *evon.Node)) {
+	prefix := root.Name
+	if prefix != "" {
+		prefix += evon.ObjectSplitter
+	}
+
+	var walk func(nodes []*evon.Node)
+	walk = func(nodes []*evon.Node) {
+		for _, n := range nodes {
+			f(strings.TrimPrefix(n.Name, prefix), n)
+			walk(n.InnerNodes)
+		}
+	}
+
+	walk(root.InnerNodes)
+}
+
+// evonJoinPath joins name of root node with relative name
+func evonJoinPath(root *evon.Node, name string) string {
+	if root.Name == "" {
+		return name
+	}
+
+	return root.Name + evon.ObjectSplitter + name
+}
+
+func evonErrorOrNil(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return evon.MultiError(errs)
+	}
+}
+
+func evonTypeError(n *evon.Node, tp reflect.Type, err error) *evon.UnmarshalTypeError {
+	return &evon.UnmarshalTypeError{
+		Path:   n.Name,
+		Value:  evonValueString(n.Value),
+		GoType: tp,
+		Err:    err,
+	}
+}
+
+// evonValueString returns env representation of node's value
+func evonValueString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return evonFormatTime(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func evonString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int:
+		return strconv.FormatInt(v.Int(), 10)
+	default:
+		if !v.IsValid() {
+			return ""
+		}
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func evonParseString[T ~string](n *evon.Node, dst *T) error {
+	*dst = T(evonString(reflect.ValueOf(n.Value)))
+	return nil
+}
+
+func evonParseBool[T ~bool](n *evon.Node, dst *T) error {
+	var b bool
+	var err error
+
+	switch v := reflect.ValueOf(n.Value); v.Kind() {
+	case reflect.Bool:
+		b = v.Bool()
+	case reflect.String:
+		if v.String() != "" {
+			b, err = strconv.ParseBool(v.String())
+		}
+	case reflect.Invalid:
+	default:
+		return &evon.UnsupportedTypeError{Path: n.Name, Kind: v.Kind()}
+	}
+	if err != nil {
+		return evonTypeError(n, reflect.TypeFor[T](), err)
+	}
+
+	*dst = T(b)
+	return nil
+}
+
+func evonParseInt[T ~int | ~int8 | ~int16 | ~int32 | ~int64](n *evon.Node, dst *T) error {
+	var i int64
+	var err error
+
+	switch v := reflect.ValueOf(n.Value); v.Kind() {
+	case reflect.String:
+		if v.String() != "" {
+			i, err = strconv.ParseInt(v.String(), 10, 64)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i = v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		i = int64(v.Uint())
+	case reflect.Invalid:
+	default:
+		return &evon.UnsupportedTypeError{Path: n.Name, Kind: v.Kind()}
+	}
+	if err != nil {
+		return evonTypeError(n, reflect.TypeFor[T](), err)
+	}
+
+	out := T(i)
+	if int64(out) != i {
+		return evonTypeError(n, reflect.TypeFor[T](), evonErrOverflow)
+	}
+
+	*dst = out
+	return nil
+}
+
+func evonParseUint[T ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr](n *evon.Node, dst *T) error {
+	var u uint64
+	var err error
+
+	switch v := reflect.ValueOf(n.Value); v.Kind() {
+	case reflect.String:
+		if v.String() != "" {
+			u, err = strconv.ParseUint(v.String(), 10, 64)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < 0 {
+			err = evonErrOverflow
+		}
+		u = uint64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u = v.Uint()
+	case reflect.Invalid:
+	default:
+		return &evon.UnsupportedTypeError{Path: n.Name, Kind: v.Kind()}
+	}
+	if err != nil {
+		return evonTypeError(n, reflect.TypeFor[T](), err)
+	}
+
+	out := T(u)
+	if uint64(out) != u {
+		return evonTypeError(n, reflect.TypeFor[T](), evonErrOverflow)
+	}
+
+	*dst = out
+	return nil
+}
+
+func evonParseFloat[T ~float32 | ~float64](n *evon.Node, dst *T) error {
+	var f float64
+	var err error
+
+	switch v := reflect.ValueOf(n.Value); v.Kind() {
+	case reflect.String:
+		if v.String() != "" {
+			f, err = strconv.ParseFloat(v.String(), 64)
+		}
+	case reflect.Float32, reflect.Float64:
+		f = v.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f = float64(v.Uint())
+	case reflect.Invalid:
+	default:
+		return &evon.UnsupportedTypeError{Path: n.Name, Kind: v.Kind()}
+	}
+	if err != nil {
+		return evonTypeError(n, reflect.TypeFor[T](), err)
+	}
+
+	out := T(f)
+	if math.IsInf(float64(out), 0) && !math.IsInf(f, 0) {
+		return evonTypeError(n, reflect.TypeFor[T](), evonErrOverflow)
+	}
+
+	*dst = out
+	return nil
+}
+
+func evonParseDuration[T ~int64](n *evon.Node, dst *T) error {
+	var d int64
+	var err error
+
+	switch v := reflect.ValueOf(n.Value); v.Kind() {
+	case reflect.String:
+		if v.String() != "" {
+			var parsed time.Duration
+			parsed, err = time.ParseDuration(v.String())
+			d = int64(parsed)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		d = v.Int()
+	case reflect.Invalid:
+	default:
+		return &evon.UnsupportedTypeError{Path: n.Name, Kind: v.Kind()}
+	}
+	if err != nil {
+		return evonTypeError(n, reflect.TypeFor[T](), err)
+	}
+
+	*dst = T(d)
+	return nil
+}
+
+// evonParseTime accepts the same formats as reflective unmarshal
+func evonParseTime(n *evon.Node, dst *time.Time) error {
+	switch v := n.Value.(type) {
+	case time.Time:
+		*dst = v
+	case string:
+		if v == "" {
+			return nil
+		}
+
+		for _, layout := range []string{time.DateOnly, time.DateTime, time.RFC3339Nano} {
+			t, err := time.Parse(layout, v)
+			if err == nil {
+				*dst = t
+				return nil
+			}
+		}
+
+		return evonTypeError(n, reflect.TypeFor[time.Time](), nil)
+	}
+
+	return nil
+}
+
+func evonParseText(n *evon.Node, dst encoding.TextUnmarshaler) error {
+	err := dst.UnmarshalText([]byte(evonString(reflect.ValueOf(n.Value))))
+	if err != nil {
+		return evonTypeError(n, reflect.TypeOf(dst).Elem(), err)
+	}
+
+	return nil
+}
+
+// evonParseSlice fills slice either from comma separated value
+// or from inner nodes "_[i]" of n
+func evonParseSlice[T any](n *evon.Node, dst *[]T, parse func(n *evon.Node, dst *T) error) error {
+	if len(n.InnerNodes) == 0 && n.Value != nil {
+		str := evonString(reflect.ValueOf(n.Value))
+		if str == "" {
+			return nil
+		}
+
+		parts := strings.Split(str, ",")
+		out := make([]T, len(parts))
+		for i, part := range parts {
+			err := parse(&evon.Node{Name: n.Name, Value: part}, &out[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		*dst = out
+		return nil
+	}
+
+	for _, e := range n.InnerNodes {
+		var elem T
+		err := parse(e, &elem)
+		if err != nil {
+			return err
+		}
+
+		*dst = append(*dst, elem)
+	}
+
+	return nil
+}
+
+// evonFormatTime keeps only significant part of time
+// e.g. date without time of day is formatted as time.DateOnly
+func evonFormatTime(t time.Time) string {
+	if t.Nanosecond() != 0 {
+		return t.Format(time.RFC3339Nano)
+	}
+
+	if t.Hour() != 0 ||
+		t.Minute() != 0 ||
+		t.Second() != 0 {
+		return t.Format(time.DateTime)
+	}
+
+	return t.Format(time.DateOnly)
+}
+
+// evonFormatSlice joins elements of slice of basic types with comma
+func evonFormatSlice[T any](s []T) string {
+	parts := make([]string, 0, len(s))
+	for _, e := range s {
+		parts = append(parts, fmt.Sprint(e))
+	}
+
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,310 @@
+package gentest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"go.redsock.ru/evon"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// reflectiveConfig has no generated methods,
+// so evon.Unmarshal sets its fields by reflection.
+// Config itself is unmarshalled with its generated UnmarshalEnv
+type reflectiveConfig Config
+
+type goldenCase struct {
+	name   string
+	prefix string
+	cfg    Config
+}
+
+func goldenCases() []goldenCase {
+	timeout := 5 * time.Second
+
+	return []goldenCase{
+		{
+			name: "empty",
+		},
+		{
+			name:   "full",
+			prefix: "app",
+			cfg: Config{
+				AppInfo: AppInfo{
+					Name:            "evon",
+					Version:         "v0.0.1",
+					StartupDuration: 10 * time.Second,
+					StartedAt:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				},
+				Postgres: &Postgres{
+					Host:     "localhost",
+					Port:     5432,
+					SslMode:  "disable",
+					Password: "pwd",
+				},
+				Servers: []Server{
+					{
+						Name: "rest",
+						Port: 8080,
+						Tags: []string{"public", "http"},
+					},
+					{
+						Name: "grpc",
+						Port: 50051,
+					},
+				},
+				Ports:    []uint16{80, 443},
+				Mode:     "release",
+				LogLevel: "info",
+				Debug:    true,
+				Ratio:    0.5,
+				Timeout:  &timeout,
+				Internal: "skipped",
+				Redis: struct {
+					Host string
+					Db   int8
+				}{
+					Host: "redis",
+					Db:   2,
+				},
+			},
+		},
+		{
+			name:   "proxy",
+			prefix: "APP",
+			cfg: Config{
+				Servers: []Server{
+					{
+						Name: "proxied",
+						Proxy: &Postgres{
+							Host: "proxy",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Test_GeneratedMarshal proves that generated MarshalEnv
+// produces the same nodes as reflective MarshalEnv
+func Test_GeneratedMarshal(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range goldenCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			reflective, err := evon.MarshalEnvWithPrefix(tc.prefix, &tc.cfg)
+			require.NoError(t, err)
+
+			generated, err := tc.cfg.MarshalEnv(tc.prefix)
+			require.NoError(t, err)
+
+			require.Equal(t, normalize(reflective.InnerNodes), normalize(generated))
+
+			assertGolden(t, tc.name, evon.Marshal(generated))
+		})
+	}
+}
+
+// Test_GeneratedUnmarshal proves that generated UnmarshalEnv
+// sets the same values as reflective Unmarshal
+func Test_GeneratedUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range goldenCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			src, err := os.ReadFile(goldenPath(tc.name))
+			require.NoError(t, err)
+
+			reflective := reflectiveConfig(newConfig())
+			err = evon.UnmarshalWithNodesAndPrefix(tc.prefix, evon.ParseToNodes(src), &reflective)
+			require.NoError(t, err)
+
+			generated := newConfig()
+			ns := evon.ParseToNodes(src)
+			root, ok := ns[strings.ToUpper(tc.prefix)]
+			if ok {
+				require.NoError(t, generated.UnmarshalEnv(root))
+			}
+
+			require.Equal(t, Config(reflective), generated)
+
+			dispatched := newConfig()
+			err = evon.UnmarshalWithNodesAndPrefix(tc.prefix, evon.ParseToNodes(src), &dispatched)
+			require.NoError(t, err)
+			require.Equal(t, generated, dispatched)
+		})
+	}
+}
+
+func Test_GeneratedUnmarshalErrors(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`APP_POSTGRES_PORT=70000
+APP_DEBUG=sure
+APP_SERVERS_[0]_PORT=8080
+APP_SERVERS_[1]_NAME=grpc`)
+
+	reflective := reflectiveConfig(newConfig())
+	reflectiveErr := evon.UnmarshalWithPrefix("APP", src, &reflective)
+
+	generated := newConfig()
+	generatedErr := generated.UnmarshalEnv(evon.ParseToNodes(src)["APP"])
+
+	require.ElementsMatch(t, errorMessages(t, reflectiveErr), errorMessages(t, generatedErr))
+	require.Contains(t, errorMessages(t, generatedErr), "missing required variable: APP_SERVERS_[0]_NAME")
+}
+
+// Test_GeneratedUnmarshalUnsupportedValue checks values
+// of kinds that can't be converted into field e.g. int into bool
+func Test_GeneratedUnmarshalUnsupportedValue(t *testing.T) {
+	t.Parallel()
+
+	newRoot := func() *evon.Node {
+		return &evon.Node{
+			Name: "APP",
+			InnerNodes: []*evon.Node{
+				{Name: "APP_DEBUG", Value: 1},
+				{Name: "APP_RATIO", Value: true},
+			},
+		}
+	}
+
+	reflective := reflectiveConfig(newConfig())
+	reflectiveErr := evon.UnmarshalWithNodesAndPrefix("APP",
+		evon.NodesToStorage(&evon.Node{InnerNodes: []*evon.Node{newRoot()}}), &reflective)
+	require.ErrorIs(t, reflectiveErr, evon.ErrUnsupportedType)
+
+	generated := newConfig()
+	generatedErr := generated.UnmarshalEnv(newRoot())
+
+	require.ElementsMatch(t, errorMessages(t, reflectiveErr), errorMessages(t, generatedErr))
+}
+
+// Test_GeneratedUnmarshalValidation checks that tag rules and Validator
+// fail the same way in reflective, generated and dispatched unmarshal
+func Test_GeneratedUnmarshalValidation(t *testing.T) {
+	t.Parallel()
+
+	src := []byte(`APP_RATIO=2
+APP_POSTGRES_SSL-MODE=disable
+APP_SERVERS_[0]_NAME=rest`)
+
+	expected := []string{
+		"APP_RATIO: must be less than or equal to 1",
+		"APP_POSTGRES: ssl mode is set without host",
+	}
+
+	reflective := reflectiveConfig(newConfig())
+	reflectiveErr := evon.UnmarshalWithPrefix("APP", src, &reflective)
+
+	generated := newConfig()
+	generatedErr := generated.UnmarshalEnv(evon.ParseToNodes(src)["APP"])
+
+	dispatched := newConfig()
+	dispatchedErr := evon.UnmarshalWithPrefix("APP", src, &dispatched)
+
+	for _, err := range []error{reflectiveErr, generatedErr, dispatchedErr} {
+		require.ErrorIs(t, err, evon.ErrValidation)
+		require.ElementsMatch(t, expected, validationMessages(t, err))
+	}
+
+	// rules are checked even without variables of struct
+	var empty Config
+	err := evon.Unmarshal([]byte("RATIO=1.5"), &empty)
+	require.ElementsMatch(t, []string{"RATIO: must be less than or equal to 1"}, validationMessages(t, err))
+}
+
+// Test_GeneratedUnmarshalFileIndirection checks that values read from files
+// reach generated UnmarshalEnv of struct as reflective fields
+func Test_GeneratedUnmarshalFileIndirection(t *testing.T) {
+	t.Parallel()
+
+	secretPath := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(secretPath, []byte("secret\n"), 0o600))
+
+	src := []byte(`APP_POSTGRES_HOST=localhost
+APP_POSTGRES_PASSWORD_FILE=` + secretPath)
+
+	reflective := reflectiveConfig(newConfig())
+	require.NoError(t, evon.UnmarshalWithPrefix("APP", src, &reflective, evon.WithFileIndirection("")))
+
+	dispatched := newConfig()
+	require.NoError(t, evon.UnmarshalWithPrefix("APP", src, &dispatched, evon.WithFileIndirection("")))
+
+	require.Equal(t, "secret", reflective.Postgres.Password)
+	require.Equal(t, *reflective.Postgres, *dispatched.Postgres)
+}
+
+func validationMessages(t *testing.T, err error) []string {
+	var invalid evon.ValidationErrors
+	require.ErrorAs(t, err, &invalid)
+
+	out := make([]string, 0, len(invalid))
+	for _, e := range invalid {
+		out = append(out, e.Error())
+	}
+
+	return out
+}
+
+func errorMessages(t *testing.T, err error) []string {
+	var multi evon.MultiError
+	require.ErrorAs(t, err, &multi)
+
+	out := make([]string, 0, len(multi))
+	for _, e := range multi {
+		out = append(out, e.Error())
+	}
+
+	return out
+}
+
+// newConfig returns config with allocated pointers
+// because unmarshal skips fields behind nil pointers
+func newConfig() Config {
+	return Config{
+		Postgres: &Postgres{},
+		Timeout:  new(time.Duration),
+	}
+}
+
+func normalize(nodes []*evon.Node) []*evon.Node {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	for _, n := range nodes {
+		if n != nil {
+			n.InnerNodes = normalize(n.InnerNodes)
+		}
+	}
+
+	return nodes
+}
+
+func goldenPath(name string) string {
+	return filepath.Join("testdata", name+".env")
+}
+
+func assertGolden(t *testing.T, name string, actual []byte) {
+	path := goldenPath(name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, actual, 0o644))
+	}
+
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(actual))
+}
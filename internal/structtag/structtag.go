@@ -0,0 +1,132 @@
+// Package structtag parses evon (or env) struct tags.
+// It's shared by evon and evon-gen, so reflective and generated code
+// name and treat fields the same way
+package structtag
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+
+	"go.redsock.ru/toolbox"
+)
+
+const (
+	evonTag = "evon"
+	envTag  = "env"
+
+	separator = ","
+
+	tagSkip      = "-"
+	tagOmitempty = "omitempty"
+	tagNonempty  = "nonempty"
+	tagRequired  = "required"
+	tagSecret    = "secret"
+	tagMin       = "min="
+	tagMax       = "max="
+	tagOneOf     = "oneof="
+	tagRegex     = "regex="
+
+	OneOfSeparator = "|"
+)
+
+// Tag is a parsed evon (or env) struct tag
+// e.g. `evon:"PORT,omitempty,min=1,max=65535"`
+//
+// Required means that variable must be presented in source,
+// otherwise *evon.MissingRequiredError is returned on unmarshal.
+//
+// Secret marks nodes of field as evon.Node.Secret on marshal
+// e.g. `evon:"PASSWORD,secret"`.
+//
+// Regex rule takes the rest of the tag, so it must be the last one:
+// `evon:"NAME,nonempty,regex=^[a-z]{1,3}$"`
+type Tag struct {
+	Name      string
+	Skip      bool
+	Omitempty bool
+	Required  bool
+	Secret    bool
+
+	Nonempty bool
+	Min      string
+	Max      string
+	OneOf    []string
+	Regex    string
+}
+
+// Lookup returns value of evon tag or env tag if the first one is empty
+func Lookup(st reflect.StructTag) string {
+	return toolbox.Coalesce(st.Get(evonTag), st.Get(envTag))
+}
+
+// Parse parses value of evon tag.
+// Order of parts is fixed: name is always the first part and options follow it.
+// "-" skips field only as the first part.
+// "omitempty" is an option at any position, so `evon:"omitempty"`
+// is a field without explicit name.
+// Unknown options are ignored, so e.g. `evon:"omitempty,NAME"`
+// has no name and NAME is dropped
+func Parse(raw string) Tag {
+	parts := strings.Split(raw, separator)
+
+	t := Tag{}
+
+	for idx, part := range parts {
+		switch {
+		case part == tagSkip && idx == 0:
+			t.Skip = true
+			return t
+		case part == tagOmitempty:
+			t.Omitempty = true
+		case idx == 0:
+			t.Name = part
+		case part == tagNonempty:
+			t.Nonempty = true
+		case part == tagRequired:
+			t.Required = true
+		case part == tagSecret:
+			t.Secret = true
+		case strings.HasPrefix(part, tagMin):
+			t.Min = part[len(tagMin):]
+		case strings.HasPrefix(part, tagMax):
+			t.Max = part[len(tagMax):]
+		case strings.HasPrefix(part, tagOneOf):
+			t.OneOf = strings.Split(part[len(tagOneOf):], OneOfSeparator)
+		case strings.HasPrefix(part, tagRegex):
+			t.Regex = strings.Join(parts[idx:], separator)[len(tagRegex):]
+			return t
+		}
+	}
+
+	return t
+}
+
+// FieldName returns name of tag or kebab-cased goName if tag has no name
+// e.g. "SslMode" -> "Ssl-Mode"
+func (t Tag) FieldName(goName string) string {
+	if t.Name != "" {
+		return t.Name
+	}
+
+	return SplitToKebab(goName)
+}
+
+// HasRules reports if tag has validation rules
+func (t Tag) HasRules() bool {
+	return t.Nonempty || t.Min != "" || t.Max != "" || len(t.OneOf) != 0 || t.Regex != ""
+}
+
+func SplitToKebab(in string) string {
+	inR := []rune(in)
+	out := make([]rune, 0, len(inR)+2)
+	for idx, r := range inR {
+		if unicode.IsUpper(r) && idx != 0 {
+			out = append(out, '-')
+		}
+
+		out = append(out, r)
+	}
+
+	return string(out)
+}
@@ -0,0 +1,88 @@
+package structtag
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Parse(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		raw      string
+		expected Tag
+	}
+
+	tests := map[string]testCase{
+		"EMPTY": {
+			raw:      "",
+			expected: Tag{},
+		},
+		"SKIP": {
+			raw:      "-",
+			expected: Tag{Skip: true},
+		},
+		"SKIP_NOT_FIRST": {
+			raw:      "NAME,-",
+			expected: Tag{Name: "NAME"},
+		},
+		"NAME_NOT_FIRST": {
+			raw:      "omitempty,NAME",
+			expected: Tag{Omitempty: true},
+		},
+		"UNKNOWN_OPTION": {
+			raw:      "NAME,optional,required",
+			expected: Tag{Name: "NAME", Required: true},
+		},
+		"LONE_OMITEMPTY": {
+			raw:      "omitempty",
+			expected: Tag{Omitempty: true},
+		},
+		"OPTIONS": {
+			raw: "PASSWORD,omitempty,required,secret,nonempty",
+			expected: Tag{
+				Name:      "PASSWORD",
+				Omitempty: true,
+				Required:  true,
+				Secret:    true,
+				Nonempty:  true,
+			},
+		},
+		"RULES": {
+			raw: "PORT,min=1,max=65535,oneof=80|443,regex=^[0-9]{2,3}$",
+			expected: Tag{
+				Name:  "PORT",
+				Min:   "1",
+				Max:   "65535",
+				OneOf: []string{"80", "443"},
+				Regex: "^[0-9]{2,3}$",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			require.Equal(t, tc.expected, Parse(tc.raw))
+		})
+	}
+}
+
+func Test_Lookup(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "A", Lookup(reflect.StructTag(`evon:"A" env:"B"`)))
+	require.Equal(t, "B", Lookup(reflect.StructTag(`env:"B"`)))
+	require.Equal(t, "", Lookup(reflect.StructTag(`json:"c"`)))
+}
+
+func Test_FieldName(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "PORT", Parse("PORT,omitempty").FieldName("Port"))
+	require.Equal(t, "Ssl-Mode", Parse("omitempty").FieldName("SslMode"))
+	require.Equal(t, "Ssl-Mode", Parse(",omitempty").FieldName("SslMode"))
+}
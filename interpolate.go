@@ -0,0 +1,281 @@
+package evon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInterpolationCycle = errors.New("interpolation cycle")
+	ErrRequiredVariable   = errors.New("required variable is not set")
+	ErrBadSubstitution    = errors.New("bad substitution")
+)
+
+type interpolateOpts struct {
+	lookupEnv  func(string) (string, bool)
+	lookupFile func(string) (string, bool, error)
+}
+
+type InterpolateOpt func(o *interpolateOpts)
+
+// WithEnvLookup resolves references that are not presented in storage
+// against process environment
+func WithEnvLookup() InterpolateOpt {
+	return func(o *interpolateOpts) {
+		o.lookupEnv = os.LookupEnv
+	}
+}
+
+// withFileLookup resolves references that are not presented in storage
+// with values of files e.g. DB_PWD is read from file set in DB_PWD_FILE
+func withFileLookup(lookup func(name string) (string, bool, error)) InterpolateOpt {
+	return func(o *interpolateOpts) {
+		o.lookupFile = lookup
+	}
+}
+
+// Interpolate replaces references to other variables inside string values of storage.
+// Supported syntax:
+//
+//	${VAR}            - value of VAR, empty if VAR is not set
+//	$VAR              - same as ${VAR}. Name consists of letters, digits, "_" and "-"
+//	${VAR:-default}   - value of VAR or default if VAR is not set or empty
+//	${VAR:?message}   - value of VAR or ErrRequiredVariable with message if VAR is not set or empty
+//	$$                - literal "$"
+//
+// References are resolved within storage first and then against process environment
+// if WithEnvLookup is passed.
+// Reference cycles are reported as ErrInterpolationCycle with full chain e.g. "A -> B -> A"
+func Interpolate(ns NodeStorage, opts ...InterpolateOpt) error {
+	in := &interpolator{
+		ns:        ns,
+		resolved:  map[string]string{},
+		resolving: map[string]bool{},
+	}
+
+	for _, opt := range opts {
+		opt(&in.opts)
+	}
+
+	keys := make([]string, 0, len(ns))
+	for key := range ns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		n := ns[key]
+		if _, ok := n.Value.(string); !ok {
+			continue
+		}
+
+		v, err := in.resolve(key)
+		if err != nil {
+			return err
+		}
+
+		n.Value = v
+	}
+
+	return nil
+}
+
+type interpolator struct {
+	ns   NodeStorage
+	opts interpolateOpts
+
+	resolved  map[string]string
+	resolving map[string]bool
+	chain     []string
+}
+
+func (in *interpolator) resolve(key string) (string, error) {
+	v, ok := in.resolved[key]
+	if ok {
+		return v, nil
+	}
+
+	if in.resolving[key] {
+		chain := append([]string{}, in.chain[indexOf(in.chain, key):]...)
+		chain = append(chain, key)
+		return "", fmt.Errorf("%w: %s", ErrInterpolationCycle, strings.Join(chain, " -> "))
+	}
+
+	in.resolving[key] = true
+	in.chain = append(in.chain, key)
+
+	raw := valueToString(in.ns[key].Value)
+	v, err := in.expand(raw)
+	if err != nil {
+		return "", err
+	}
+
+	in.chain = in.chain[:len(in.chain)-1]
+	delete(in.resolving, key)
+	in.resolved[key] = v
+
+	return v, nil
+}
+
+func (in *interpolator) lookup(name string) (string, bool, error) {
+	n, ok := in.ns[name]
+	if ok && n.Value != nil {
+		if _, isString := n.Value.(string); !isString {
+			return valueToString(n.Value), true, nil
+		}
+
+		v, err := in.resolve(name)
+		if err != nil {
+			return "", false, err
+		}
+
+		return v, true, nil
+	}
+
+	if in.opts.lookupFile != nil {
+		v, ok, err := in.opts.lookupFile(name)
+		if err != nil || ok {
+			return v, ok, err
+		}
+	}
+
+	if in.opts.lookupEnv != nil {
+		v, ok := in.opts.lookupEnv(name)
+		return v, ok, nil
+	}
+
+	return "", false, nil
+}
+
+func (in *interpolator) expand(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	b := strings.Builder{}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch next := s[i+1]; {
+		case next == '$':
+			b.WriteByte('$')
+			i++
+
+		case next == '{':
+			end := closingBrace(s, i+2)
+			if end == -1 {
+				return "", fmt.Errorf("%w: unclosed brace in %q", ErrBadSubstitution, s)
+			}
+
+			v, err := in.substitute(s[i+2 : end])
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(v)
+			i = end
+
+		case isVarNameChar(next):
+			end := i + 1
+			for end < len(s) && isVarNameChar(s[end]) {
+				end++
+			}
+
+			v, _, err := in.lookup(s[i+1 : end])
+			if err != nil {
+				return "", err
+			}
+
+			b.WriteString(v)
+			i = end - 1
+
+		default:
+			b.WriteByte('$')
+		}
+	}
+
+	return b.String(), nil
+}
+
+// substitute handles content of ${...}
+func (in *interpolator) substitute(expr string) (string, error) {
+	name, operand, op := expr, "", ""
+	if idx := strings.Index(expr, ":"); idx != -1 && idx+1 < len(expr) {
+		switch expr[idx+1] {
+		case '-', '?':
+			name, op, operand = expr[:idx], expr[idx:idx+2], expr[idx+2:]
+		}
+	}
+
+	if name == "" {
+		return "", fmt.Errorf("%w: ${%s}", ErrBadSubstitution, expr)
+	}
+
+	v, ok, err := in.lookup(name)
+	if err != nil {
+		return "", err
+	}
+
+	if ok && v != "" {
+		return v, nil
+	}
+
+	switch op {
+	case ":-":
+		return in.expand(operand)
+	case ":?":
+		msg, err := in.expand(operand)
+		if err != nil {
+			return "", err
+		}
+
+		if msg == "" {
+			return "", fmt.Errorf("%w: %s", ErrRequiredVariable, name)
+		}
+
+		return "", fmt.Errorf("%w: %s: %s", ErrRequiredVariable, name, msg)
+	}
+
+	return v, nil
+}
+
+func closingBrace(s string, from int) int {
+	depth := 1
+	for i := from; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+func isVarNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' ||
+		c == '_' || c == '-'
+}
+
+func indexOf(s []string, v string) int {
+	for i := range s {
+		if s[i] == v {
+			return i
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,278 @@
+package evon
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type jsonOpts struct {
+	stringsOnly bool
+}
+
+type JSONOpt func(o *jsonOpts)
+
+// WithJSONStringsOnly makes ToJSON emit every value as json string
+// e.g. {"port": "8080"} instead of {"port": 8080}
+func WithJSONStringsOnly() JSONOpt {
+	return func(o *jsonOpts) {
+		o.stringsOnly = true
+	}
+}
+
+// FromJSON converts json document into tree of nodes.
+// Object keys are upper-cased and "_" in them is replaced with FieldSplitter,
+// array elements are stored as "_[i]" nodes.
+// Numbers are stored as their literal, so no precision is lost.
+// Keys order of document is preserved
+// e.g.
+//
+//	{"server": {"http_port": 8080, "hosts": ["a", "b"]}}
+//
+// is converted into
+//
+//	SERVER_HTTP-PORT=8080
+//	SERVER_HOSTS_[0]=a
+//	SERVER_HOSTS_[1]=b
+func FromJSON(data []byte) (*Node, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	root := &Node{}
+
+	err := fromJSONValue(dec, root)
+	if err != nil {
+		if errors.Is(err, io.EOF) && len(bytes.TrimSpace(data)) == 0 {
+			return root, nil
+		}
+
+		return nil, fmt.Errorf("error parsing json: %w", err)
+	}
+
+	_, err = dec.Token()
+	if !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error parsing json: unexpected data after top-level value")
+	}
+
+	return root, nil
+}
+
+func fromJSONValue(dec *json.Decoder, n *Node) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			err = fromJSONObject(dec, n)
+		case '[':
+			err = fromJSONArray(dec, n)
+		}
+		if err != nil {
+			return err
+		}
+
+		// closing delimiter
+		_, err = dec.Token()
+		return err
+
+	case string:
+		n.Value = v
+	case json.Number:
+		n.Value = v.String()
+	case bool:
+		n.Value = strconv.FormatBool(v)
+	case nil:
+		n.Value = nil
+	}
+
+	return nil
+}
+
+func fromJSONObject(dec *json.Decoder, n *Node) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+
+		key, _ := tok.(string)
+		name := joinPath(n.Name, strings.ToUpper(nameToEvonName(key)))
+
+		inner := findInnerNode(n, name)
+		if inner == nil {
+			inner = &Node{Name: name}
+			n.InnerNodes = append(n.InnerNodes, inner)
+		}
+
+		err = fromJSONValue(dec, inner)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func fromJSONArray(dec *json.Decoder, n *Node) error {
+	for idx := 0; dec.More(); idx++ {
+		inner := &Node{
+			Name: joinPath(n.Name, "["+strconv.Itoa(idx)+"]"),
+		}
+
+		err := fromJSONValue(dec, inner)
+		if err != nil {
+			return err
+		}
+
+		n.InnerNodes = append(n.InnerNodes, inner)
+	}
+
+	return nil
+}
+
+// ToJSON converts tree of nodes into json document.
+// Names are lower-cased and FieldSplitter in them is replaced with "_",
+// nodes with "_[i]" inner nodes become arrays.
+// By default values that look like json numbers and bools
+// are emitted unquoted. See WithJSONStringsOnly
+func ToJSON(n *Node, opts ...JSONOpt) ([]byte, error) {
+	o := jsonOpts{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	b := &bytes.Buffer{}
+
+	err := o.writeNode(b, n)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func (o jsonOpts) writeNode(b *bytes.Buffer, n *Node) error {
+	if len(n.InnerNodes) == 0 {
+		o.writeValue(b, n.Value)
+		return nil
+	}
+
+	if n.Value != nil {
+		return fmt.Errorf("node %s has both value and inner nodes and can't be converted to json", n.Name)
+	}
+
+	inner, isArray := sliceElements(n)
+	if isArray {
+		b.WriteByte('[')
+		for idx, elem := range inner {
+			if idx > 0 {
+				b.WriteByte(',')
+			}
+
+			err := o.writeNode(b, elem)
+			if err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+
+		return nil
+	}
+
+	b.WriteByte('{')
+	for idx, child := range n.InnerNodes {
+		if idx > 0 {
+			b.WriteByte(',')
+		}
+
+		writeJSONString(b, fieldNameToKey(relativeChildName(n.Name, child.Name)))
+		b.WriteByte(':')
+
+		err := o.writeNode(b, child)
+		if err != nil {
+			return err
+		}
+	}
+	b.WriteByte('}')
+
+	return nil
+}
+
+func (o jsonOpts) writeValue(b *bytes.Buffer, v any) {
+	if v == nil {
+		b.WriteString("null")
+		return
+	}
+
+	s := valueToString(v)
+	if !o.stringsOnly && (s == "true" || s == "false" || isJSONNumber(s)) {
+		b.WriteString(s)
+		return
+	}
+
+	writeJSONString(b, s)
+}
+
+func writeJSONString(b *bytes.Buffer, s string) {
+	// marshalling of string never fails
+	encoded, _ := json.Marshal(s)
+	b.Write(encoded)
+}
+
+// isJSONNumber checks that s is a number literal as defined by json grammar.
+// Numbers with leading zeros or "+" sign are not json numbers
+func isJSONNumber(s string) bool {
+	i := 0
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case i < len(s) && s[i] >= '1' && s[i] <= '9':
+		i = skipDigits(s, i)
+	default:
+		return false
+	}
+
+	if i < len(s) && s[i] == '.' {
+		i++
+		start := i
+		i = skipDigits(s, i)
+		if i == start {
+			return false
+		}
+	}
+
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+
+		start := i
+		i = skipDigits(s, i)
+		if i == start {
+			return false
+		}
+	}
+
+	return i == len(s)
+}
+
+func skipDigits(s string, i int) int {
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+
+	return i
+}
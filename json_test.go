@@ -0,0 +1,106 @@
+package evon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromJSON(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected string
+	}
+
+	tests := map[string]testCase{
+		"EMPTY": {
+			input:    " ",
+			expected: "",
+		},
+		"NESTED": {
+			input: `{"server": {"http_port": 8080, "tls": true}, "name": "loki"}`,
+			expected: `SERVER_HTTP-PORT=8080
+SERVER_TLS=true
+NAME=loki
+`,
+		},
+		"ARRAYS": {
+			input: `{"hosts": ["a", "b"], "configs": [{"from": "2020-10-24"}, {"from": "2021-01-01"}]}`,
+			expected: `HOSTS_[0]=a
+HOSTS_[1]=b
+CONFIGS_[0]_FROM=2020-10-24
+CONFIGS_[1]_FROM=2021-01-01
+`,
+		},
+		"PRECISION": {
+			input: `{"big": 12345678901234567890, "ratio": 0.10000000000000000555, "exp": 1e400}`,
+			expected: `BIG=12345678901234567890
+RATIO=0.10000000000000000555
+EXP=1e400
+`,
+		},
+		"NULL": {
+			input: `{"a": null, "b": ""}`,
+			expected: `B=
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root, err := FromJSON([]byte(tc.input))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(Marshal(root.InnerNodes)))
+		})
+	}
+}
+
+func Test_FromJSONError(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{`{"a": `, `{"a": 1}}`, `{"a": 1} {}`, `[1,]`} {
+		_, err := FromJSON([]byte(input))
+		require.ErrorContains(t, err, "error parsing json", input)
+	}
+}
+
+func Test_ToJSON(t *testing.T) {
+	t.Parallel()
+
+	ns := ParseToNodes([]byte(`
+AUTH-ENABLED=false
+SERVER_HTTP-LISTEN-PORT=3100
+SERVER_NAME=loki "main"
+LIMITS_RATIO=0.10000000000000000555
+LIMITS_ZIP=007
+PEERS_[1]=b
+PEERS_[0]=a
+EMPTY=
+`))
+
+	actual, err := ToJSON(ns[""])
+	require.NoError(t, err)
+	require.Equal(t, `{"auth_enabled":false,"server":{"http_listen_port":3100,"name":"loki \"main\""},"limits":{"ratio":0.10000000000000000555,"zip":"007"},"peers":["a","b"],"empty":""}`, string(actual))
+
+	actual, err = ToJSON(ns[""], WithJSONStringsOnly())
+	require.NoError(t, err)
+	require.Equal(t, `{"auth_enabled":"false","server":{"http_listen_port":"3100","name":"loki \"main\""},"limits":{"ratio":"0.10000000000000000555","zip":"007"},"peers":["a","b"],"empty":""}`, string(actual))
+}
+
+func Test_ToJSONMarshalled(t *testing.T) {
+	t.Parallel()
+
+	root, err := MarshalEnv(NewTestObject())
+	require.NoError(t, err)
+
+	actual, err := ToJSON(root)
+	require.NoError(t, err)
+
+	back, err := FromJSON(actual)
+	require.NoError(t, err)
+	require.True(t, Diff(root, back).IsEmpty(), string(actual))
+}
@@ -0,0 +1,175 @@
+package evon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	defaultMaxFileSize = 1 << 20
+)
+
+var (
+	ErrFileTooLarge = errors.New("file is too large")
+)
+
+// KeyPerFileSource reads directory where every file is a single variable
+// e.g. Docker secrets (/run/secrets/DB_PWD) or Kubernetes projected volumes.
+// File names are used as keys, nested directories - as ObjectSplitter separated segments:
+//
+//	/run/secrets/DATA-SOURCES/POSTGRES/PWD -> DATA-SOURCES_POSTGRES_PWD
+//
+// Trailing newlines are trimmed. Hidden files (including Kubernetes' "..data") are skipped.
+// Returns ErrMissingSource if directory doesn't exist
+func KeyPerFileSource(dir string, opts ...KeyPerFileOpt) Source {
+	s := &keyPerFileSource{
+		dir:         dir,
+		maxFileSize: defaultMaxFileSize,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+type KeyPerFileOpt func(s *keyPerFileSource)
+
+// WithMaxFileSize limits size of a single file. Default is 1 MiB
+func WithMaxFileSize(size int64) KeyPerFileOpt {
+	return func(s *keyPerFileSource) {
+		s.maxFileSize = size
+	}
+}
+
+// WithFollowSymlinks enables reading of symlinked files and directories
+// the way Kubernetes mounts ConfigMaps and Secrets (KEY -> ..data/KEY).
+// Symlinks are skipped by default
+func WithFollowSymlinks() KeyPerFileOpt {
+	return func(s *keyPerFileSource) {
+		s.followSymlinks = true
+	}
+}
+
+type keyPerFileSource struct {
+	dir            string
+	maxFileSize    int64
+	followSymlinks bool
+}
+
+func (k *keyPerFileSource) Load(_ context.Context) (NodeStorage, error) {
+	_, err := os.Stat(k.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: directory %s doesn't exist", ErrMissingSource, k.dir)
+		}
+
+		return nil, fmt.Errorf("error reading directory: %w", err)
+	}
+
+	ns := NodeStorage{}
+
+	err = k.readDir(ns, k.dir, "", map[string]struct{}{})
+	if err != nil {
+		return nil, err
+	}
+
+	return ns, nil
+}
+
+func (k *keyPerFileSource) String() string {
+	return k.dir
+}
+
+func (k *keyPerFileSource) readDir(ns NodeStorage, dir, prefix string, visited map[string]struct{}) error {
+	realDir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return fmt.Errorf("error resolving directory %s: %w", dir, err)
+	}
+
+	if _, ok := visited[realDir]; ok {
+		return nil
+	}
+	visited[realDir] = struct{}{}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("error reading directory %s: %w", dir, err)
+	}
+
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, e.Name())
+		key := prefix + e.Name()
+
+		info, err := e.Info()
+		if err != nil {
+			return fmt.Errorf("error reading file info %s: %w", path, err)
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			if !k.followSymlinks {
+				continue
+			}
+
+			info, err = os.Stat(path)
+			if err != nil {
+				return fmt.Errorf("error following symlink %s: %w", path, err)
+			}
+		}
+
+		if info.IsDir() {
+			err = k.readDir(ns, path, key+ObjectSplitter, visited)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		b, err := readFileLimited(path, k.maxFileSize)
+		if err != nil {
+			return fmt.Errorf("error reading file: %w", err)
+		}
+
+		ns.AddNode(&Node{
+			Name:  key,
+			Value: strings.TrimRight(string(b), "\r\n"),
+		})
+	}
+
+	return nil
+}
+
+// readFileLimited reads at most maxSize bytes of file
+// and returns ErrFileTooLarge if there are more
+func readFileLimited(path string, maxSize int64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(b)) > maxSize {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrFileTooLarge, path, maxSize)
+	}
+
+	return b, nil
+}
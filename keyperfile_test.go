@@ -0,0 +1,69 @@
+package evon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPerFileSource(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "DATA-SOURCES", "POSTGRES"), 0o700))
+	writeTestFile(t, filepath.Join(dir, "API-KEY"), "key\n")
+	writeTestFile(t, filepath.Join(dir, "DATA-SOURCES", "POSTGRES", "PWD"), "secret\r\n")
+	writeTestFile(t, filepath.Join(dir, ".hidden"), "hidden")
+
+	ns, err := KeyPerFileSource(dir).Load(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, `API-KEY=key
+DATA-SOURCES_POSTGRES_PWD=secret
+`, string(Marshal(ns[""].InnerNodes)))
+}
+
+func TestKeyPerFileSourceKubernetesSymlinks(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	// Kubernetes layout:
+	//	..2024_10_19_10_00_00.000/DB_PWD
+	//	..data -> ..2024_10_19_10_00_00.000
+	//	DB_PWD -> ..data/DB_PWD
+	const tsDir = "..2024_10_19_10_00_00.000"
+	require.NoError(t, os.Mkdir(filepath.Join(dir, tsDir), 0o700))
+	writeTestFile(t, filepath.Join(dir, tsDir, "DB_PWD"), "secret\n")
+	require.NoError(t, os.Symlink(tsDir, filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "DB_PWD"), filepath.Join(dir, "DB_PWD")))
+
+	ns, err := KeyPerFileSource(dir).Load(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, ns[""])
+
+	ns, err = KeyPerFileSource(dir, WithFollowSymlinks()).Load(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "DB_PWD=secret\n", string(Marshal(ns[""].InnerNodes)))
+}
+
+func TestKeyPerFileSourceErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "CERT"), "0123456789")
+
+	_, err := KeyPerFileSource(dir, WithMaxFileSize(9)).Load(context.Background())
+	require.ErrorIs(t, err, ErrFileTooLarge)
+
+	ns, err := KeyPerFileSource(dir, WithMaxFileSize(10)).Load(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", ns["CERT"].Value)
+
+	_, err = KeyPerFileSource(filepath.Join(dir, "missing")).Load(context.Background())
+	require.ErrorIs(t, err, ErrMissingSource)
+}
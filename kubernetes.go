@@ -0,0 +1,296 @@
+package evon
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvSnippet is a style of Deployment container snippet
+// referencing generated ConfigMap and Secret
+type EnvSnippet int
+
+const (
+	// NoEnvSnippet disables snippet generation
+	NoEnvSnippet EnvSnippet = iota
+	// EnvFromSnippet references whole ConfigMap and Secret
+	// e.g.
+	//
+	//	envFrom:
+	//	  - configMapRef:
+	//	      name: app
+	EnvFromSnippet
+	// EnvKeyRefSnippet references every variable separately
+	// e.g.
+	//
+	//	env:
+	//	  - name: DB_HOST
+	//	    valueFrom:
+	//	      configMapKeyRef:
+	//	        name: app
+	//	        key: DB_HOST
+	EnvKeyRefSnippet
+)
+
+// configMapKeyRegexp is a rule of kubernetes for ConfigMap and Secret keys
+var configMapKeyRegexp = regexp.MustCompile(`^[-._a-zA-Z0-9]+$`)
+
+type kubernetesOpts struct {
+	namespace string
+	labels    map[string]string
+	snippet   EnvSnippet
+}
+
+type KubernetesOpt func(o *kubernetesOpts)
+
+// WithKubernetesNamespace sets metadata.namespace of manifests
+func WithKubernetesNamespace(namespace string) KubernetesOpt {
+	return func(o *kubernetesOpts) {
+		o.namespace = namespace
+	}
+}
+
+// WithKubernetesLabels sets metadata.labels of manifests
+func WithKubernetesLabels(labels map[string]string) KubernetesOpt {
+	return func(o *kubernetesOpts) {
+		o.labels = labels
+	}
+}
+
+// WithEnvSnippet enables generation of Deployment container snippet
+func WithEnvSnippet(snippet EnvSnippet) KubernetesOpt {
+	return func(o *kubernetesOpts) {
+		o.snippet = snippet
+	}
+}
+
+// KubernetesManifests holds yaml documents generated by ToKubernetes.
+// Fields are nil if there is nothing to put into them
+type KubernetesManifests struct {
+	ConfigMap []byte
+	Secret    []byte
+	// EnvSnippet is a part of Deployment container spec
+	// e.g. spec.template.spec.containers[0]
+	EnvSnippet []byte
+}
+
+// Bytes joins ConfigMap and Secret into single multi-document yaml
+// which can be passed to kubectl apply
+func (m KubernetesManifests) Bytes() []byte {
+	b := &bytes.Buffer{}
+	for _, doc := range [][]byte{m.ConfigMap, m.Secret} {
+		if doc == nil {
+			continue
+		}
+
+		if b.Len() > 0 {
+			b.WriteString("---\n")
+		}
+
+		b.Write(doc)
+	}
+
+	return b.Bytes()
+}
+
+type kubernetesEntry struct {
+	key    string
+	value  string
+	secret bool
+}
+
+// ToKubernetes converts tree of nodes into v1/ConfigMap and v1/Secret manifests named as name.
+// Values of nodes marked as Secret (and their inner nodes) go to Secret base64-encoded,
+// the rest go to ConfigMap. Full node names are used as keys
+// e.g. MarshalEnvWithPrefix("APP", cfg) with field `evon:"PASSWORD,secret"` gives
+//
+//	apiVersion: v1
+//	kind: ConfigMap
+//	metadata:
+//	  name: app
+//	data:
+//	  APP_DB_HOST: localhost
+//	---
+//	apiVersion: v1
+//	kind: Secret
+//	metadata:
+//	  name: app
+//	type: Opaque
+//	data:
+//	  APP_DB_PASSWORD: cHdk
+//
+// Names must be valid ConfigMap keys, so slice elements "_[i]" can't be exported
+func ToKubernetes(name string, n *Node, opts ...KubernetesOpt) (KubernetesManifests, error) {
+	o := kubernetesOpts{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var entries []kubernetesEntry
+	err := collectKubernetesEntries(n, false, &entries)
+	if err != nil {
+		return KubernetesManifests{}, err
+	}
+
+	var plain, secret []kubernetesEntry
+	for _, e := range entries {
+		if e.secret {
+			secret = append(secret, e)
+		} else {
+			plain = append(plain, e)
+		}
+	}
+
+	out := KubernetesManifests{}
+
+	if len(plain) > 0 {
+		data := yamlMapping()
+		for _, e := range plain {
+			data.Content = append(data.Content, yamlString(e.key), yamlString(e.value))
+		}
+
+		out.ConfigMap, err = encodeYAML(yamlMapping(
+			yamlString("apiVersion"), yamlString("v1"),
+			yamlString("kind"), yamlString("ConfigMap"),
+			yamlString("metadata"), o.metadata(name),
+			yamlString("data"), data,
+		))
+		if err != nil {
+			return KubernetesManifests{}, fmt.Errorf("error encoding ConfigMap: %w", err)
+		}
+	}
+
+	if len(secret) > 0 {
+		data := yamlMapping()
+		for _, e := range secret {
+			encoded := base64.StdEncoding.EncodeToString([]byte(e.value))
+			data.Content = append(data.Content, yamlString(e.key), yamlString(encoded))
+		}
+
+		out.Secret, err = encodeYAML(yamlMapping(
+			yamlString("apiVersion"), yamlString("v1"),
+			yamlString("kind"), yamlString("Secret"),
+			yamlString("metadata"), o.metadata(name),
+			yamlString("type"), yamlString("Opaque"),
+			yamlString("data"), data,
+		))
+		if err != nil {
+			return KubernetesManifests{}, fmt.Errorf("error encoding Secret: %w", err)
+		}
+	}
+
+	out.EnvSnippet, err = o.envSnippet(name, plain, secret)
+	if err != nil {
+		return KubernetesManifests{}, fmt.Errorf("error encoding env snippet: %w", err)
+	}
+
+	return out, nil
+}
+
+func collectKubernetesEntries(n *Node, secret bool, entries *[]kubernetesEntry) error {
+	secret = secret || n.Secret
+
+	if n.Value != nil {
+		if !configMapKeyRegexp.MatchString(n.Name) {
+			return fmt.Errorf("name of node %s can't be used as ConfigMap key", n.Name)
+		}
+
+		*entries = append(*entries, kubernetesEntry{
+			key:    n.Name,
+			value:  valueToString(n.Value),
+			secret: secret,
+		})
+	}
+
+	for _, child := range n.InnerNodes {
+		err := collectKubernetesEntries(child, secret, entries)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (o kubernetesOpts) metadata(name string) *yaml.Node {
+	md := yamlMapping(yamlString("name"), yamlString(name))
+	if o.namespace != "" {
+		md.Content = append(md.Content, yamlString("namespace"), yamlString(o.namespace))
+	}
+
+	if len(o.labels) > 0 {
+		keys := make([]string, 0, len(o.labels))
+		for k := range o.labels {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+
+		labels := yamlMapping()
+		for _, k := range keys {
+			labels.Content = append(labels.Content, yamlString(k), yamlString(o.labels[k]))
+		}
+
+		md.Content = append(md.Content, yamlString("labels"), labels)
+	}
+
+	return md
+}
+
+func (o kubernetesOpts) envSnippet(name string, plain, secret []kubernetesEntry) ([]byte, error) {
+	refs := &yaml.Node{Kind: yaml.SequenceNode}
+
+	switch o.snippet {
+	case NoEnvSnippet:
+		return nil, nil
+
+	case EnvFromSnippet:
+		if len(plain) > 0 {
+			refs.Content = append(refs.Content, yamlMapping(
+				yamlString("configMapRef"), yamlMapping(yamlString("name"), yamlString(name))))
+		}
+
+		if len(secret) > 0 {
+			refs.Content = append(refs.Content, yamlMapping(
+				yamlString("secretRef"), yamlMapping(yamlString("name"), yamlString(name))))
+		}
+
+		return encodeYAML(yamlMapping(yamlString("envFrom"), refs))
+
+	case EnvKeyRefSnippet:
+		for _, e := range slices.Concat(plain, secret) {
+			ref := "configMapKeyRef"
+			if e.secret {
+				ref = "secretKeyRef"
+			}
+
+			refs.Content = append(refs.Content, yamlMapping(
+				yamlString("name"), yamlString(e.key),
+				yamlString("valueFrom"), yamlMapping(
+					yamlString(ref), yamlMapping(
+						yamlString("name"), yamlString(name),
+						yamlString("key"), yamlString(e.key),
+					),
+				),
+			))
+		}
+
+		return encodeYAML(yamlMapping(yamlString("env"), refs))
+
+	default:
+		return nil, fmt.Errorf("unknown env snippet style %d", o.snippet)
+	}
+}
+
+func yamlMapping(content ...*yaml.Node) *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Content: content}
+}
+
+// yamlString returns string scalar which is quoted if needed
+// e.g. "3100" stays a string
+func yamlString(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
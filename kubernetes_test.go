@@ -0,0 +1,192 @@
+package evon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type kubernetesTestConfig struct {
+	Postgres struct {
+		Host     string `evon:"HOST"`
+		Port     int    `evon:"PORT"`
+		Password string `evon:"PASSWORD,secret"`
+	} `evon:"POSTGRES"`
+	Tokens struct {
+		Github string `evon:"GITHUB"`
+		Slack  string `evon:"SLACK"`
+	} `evon:"TOKENS,secret"`
+	Debug bool `evon:"DEBUG"`
+}
+
+func newKubernetesTestConfig() kubernetesTestConfig {
+	cfg := kubernetesTestConfig{}
+	cfg.Postgres.Host = "localhost"
+	cfg.Postgres.Port = 5432
+	cfg.Postgres.Password = "pwd"
+	cfg.Tokens.Github = "gh: token"
+	cfg.Tokens.Slack = "slack"
+	cfg.Debug = true
+
+	return cfg
+}
+
+func Test_ToKubernetes(t *testing.T) {
+	t.Parallel()
+
+	n, err := MarshalEnvWithPrefix("APP", newKubernetesTestConfig())
+	require.NoError(t, err)
+
+	actual, err := ToKubernetes("app", n,
+		WithKubernetesNamespace("prod"),
+		WithKubernetesLabels(map[string]string{
+			"tier": "backend",
+			"app":  "evon",
+		}))
+	require.NoError(t, err)
+
+	require.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+  namespace: prod
+  labels:
+    app: evon
+    tier: backend
+data:
+  APP_POSTGRES_HOST: localhost
+  APP_POSTGRES_PORT: "5432"
+  APP_DEBUG: "true"
+`, string(actual.ConfigMap))
+
+	require.Equal(t, `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+  namespace: prod
+  labels:
+    app: evon
+    tier: backend
+type: Opaque
+data:
+  APP_POSTGRES_PASSWORD: cHdk
+  APP_TOKENS_GITHUB: Z2g6IHRva2Vu
+  APP_TOKENS_SLACK: c2xhY2s=
+`, string(actual.Secret))
+
+	require.Nil(t, actual.EnvSnippet)
+	require.Equal(t, string(actual.ConfigMap)+"---\n"+string(actual.Secret), string(actual.Bytes()))
+}
+
+func Test_ToKubernetesWithoutSecrets(t *testing.T) {
+	t.Parallel()
+
+	ns := ParseToNodes([]byte("DB_HOST=localhost\nDB_PASSWORD=pwd"))
+
+	actual, err := ToKubernetes("db", ns[""], WithEnvSnippet(EnvFromSnippet))
+	require.NoError(t, err)
+
+	require.Nil(t, actual.Secret)
+	require.Equal(t, `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: db
+data:
+  DB_HOST: localhost
+  DB_PASSWORD: pwd
+`, string(actual.Bytes()))
+
+	require.Equal(t, `envFrom:
+  - configMapRef:
+      name: db
+`, string(actual.EnvSnippet))
+}
+
+func Test_ToKubernetesEnvSnippet(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		snippet  EnvSnippet
+		expected string
+	}
+
+	tests := map[string]testCase{
+		"ENV_FROM": {
+			snippet: EnvFromSnippet,
+			expected: `envFrom:
+  - configMapRef:
+      name: app
+  - secretRef:
+      name: app
+`,
+		},
+		"ENV_KEY_REF": {
+			snippet: EnvKeyRefSnippet,
+			expected: `env:
+  - name: DB_HOST
+    valueFrom:
+      configMapKeyRef:
+        name: app
+        key: DB_HOST
+  - name: DB_PASSWORD
+    valueFrom:
+      secretKeyRef:
+        name: app
+        key: DB_PASSWORD
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			n := &Node{
+				Name: "DB",
+				InnerNodes: []*Node{
+					{Name: "DB_HOST", Value: "localhost"},
+					{Name: "DB_PASSWORD", Value: "pwd", Secret: true},
+				},
+			}
+
+			actual, err := ToKubernetes("app", n, WithEnvSnippet(tc.snippet))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(actual.EnvSnippet))
+		})
+	}
+}
+
+func Test_ToKubernetesErrors(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		node *Node
+		opts []KubernetesOpt
+		err  string
+	}
+
+	tests := map[string]testCase{
+		"SLICE_ELEMENT": {
+			node: ParseToNodes([]byte("HOSTS_[0]=a"))[""],
+			err:  "name of node HOSTS_[0] can't be used as ConfigMap key",
+		},
+		"GRPC_NAME": {
+			node: ParseToNodes([]byte("SERVERS_/{GRPC}_PORT=50051"))[""],
+			err:  "name of node SERVERS_/{GRPC}_PORT can't be used as ConfigMap key",
+		},
+		"UNKNOWN_SNIPPET": {
+			node: ParseToNodes([]byte("HOST=a"))[""],
+			opts: []KubernetesOpt{WithEnvSnippet(EnvSnippet(42))},
+			err:  "error encoding env snippet: unknown env snippet style 42",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ToKubernetes("app", tc.node, tc.opts...)
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
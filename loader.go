@@ -0,0 +1,181 @@
+package evon
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	processEnvLayer = "process env"
+	dotEnvFile      = ".env"
+	localSuffix     = ".local"
+)
+
+// Loader loads variables from ordered list of sources,
+// unites them one by one (every next source overrides previous ones)
+// and unmarshalls result into destination
+// e.g.
+//
+//	err := NewLoader(
+//		WithPrefix("MATRESHKA"),
+//		WithProfile(".", os.Getenv("APP_ENV")),
+//		WithProcessEnv(),
+//	).Load(ctx, &cfg)
+type Loader struct {
+	prefix        string
+	sources       []Source
+	uniteOpts     []UniteOpt
+	unmarshalOpts []unmarshalOpt
+
+	interpolate       bool
+	interpolationOpts []InterpolateOpt
+
+	// processEnv are sources added by WithProcessEnv.
+	// They get loader's prefix after all options are applied,
+	// so order of WithPrefix and WithProcessEnv doesn't matter
+	processEnv []*processEnvSource
+}
+
+type LoaderOpt func(l *Loader)
+
+func NewLoader(opts ...LoaderOpt) *Loader {
+	l := &Loader{}
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	for _, s := range l.processEnv {
+		s.prefix = l.prefix
+	}
+
+	return l
+}
+
+// WithPrefix sets prefix for unmarshalling and filters process environment by it
+func WithPrefix(prefix string) LoaderOpt {
+	return func(l *Loader) {
+		l.prefix = strings.ToUpper(prefix)
+	}
+}
+
+// WithSource adds any source. Sources are united in order they were added
+func WithSource(s Source) LoaderOpt {
+	return func(l *Loader) {
+		l.sources = append(l.sources, s)
+	}
+}
+
+// WithFile adds mandatory file. Loading fails if it doesn't exist
+func WithFile(path string) LoaderOpt {
+	return WithSource(FileSource(path))
+}
+
+// WithOptionalFile adds file that is skipped if it doesn't exist
+func WithOptionalFile(path string) LoaderOpt {
+	return WithSource(Optional(FileSource(path)))
+}
+
+// WithProfile adds optional profile-specific cascade of files from dir:
+//
+//	.env
+//	.env.${profile}
+//	.env.local
+//	.env.${profile}.local
+//
+// Profile files are skipped when profile is empty
+func WithProfile(dir, profile string) LoaderOpt {
+	return func(l *Loader) {
+		files := []string{dotEnvFile}
+		if profile != "" {
+			files = append(files, dotEnvFile+"."+profile)
+		}
+
+		files = append(files, dotEnvFile+localSuffix)
+		if profile != "" {
+			files = append(files, dotEnvFile+"."+profile+localSuffix)
+		}
+
+		for _, f := range files {
+			WithOptionalFile(filepath.Join(dir, f))(l)
+		}
+	}
+}
+
+// WithProcessEnv adds variables of current process.
+// Only variables with loader's prefix are taken if prefix is set
+func WithProcessEnv() LoaderOpt {
+	return func(l *Loader) {
+		s := &processEnvSource{}
+		l.processEnv = append(l.processEnv, s)
+		l.sources = append(l.sources, s)
+	}
+}
+
+// WithUniteOpts sets options used to unite sources
+func WithUniteOpts(opts ...UniteOpt) LoaderOpt {
+	return func(l *Loader) {
+		l.uniteOpts = append(l.uniteOpts, opts...)
+	}
+}
+
+// WithUnmarshalOpts sets options used to unmarshal united nodes
+// e.g. WithFileIndirection
+func WithUnmarshalOpts(opts ...unmarshalOpt) LoaderOpt {
+	return func(l *Loader) {
+		l.unmarshalOpts = append(l.unmarshalOpts, opts...)
+	}
+}
+
+// WithInterpolation enables interpolation of united nodes. See Interpolate.
+// If WithFileIndirection is passed via WithUnmarshalOpts,
+// references to variables without value are read from their files
+// e.g. ${DB_PWD} is read from file set in DB_PWD_FILE
+func WithInterpolation(opts ...InterpolateOpt) LoaderOpt {
+	return func(l *Loader) {
+		l.interpolate = true
+		l.interpolationOpts = append(l.interpolationOpts, opts...)
+	}
+}
+
+// LoadNodes loads and unites all sources
+func (l *Loader) LoadNodes(ctx context.Context) (*LayeredStorage, error) {
+	ls, err := UniteSources(ctx, l.sources, l.uniteOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if l.interpolate {
+		opts := l.interpolationOpts
+
+		// _FILE variables are resolved by unmarshal after interpolation,
+		// so references to their plain names are read from files here
+		unOpts := newUnmarshalOpts(l.unmarshalOpts)
+		if unOpts.fileSuffix != "" {
+			opts = append(opts[:len(opts):len(opts)], withFileLookup(fileLookup(ls.Storage, unOpts)))
+		}
+
+		err = Interpolate(ls.Storage, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("error interpolating loaded nodes: %w", err)
+		}
+	}
+
+	return ls, nil
+}
+
+// Load loads and unites all sources and unmarshalls result into dst
+func (l *Loader) Load(ctx context.Context, dst any) error {
+	ls, err := l.LoadNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = UnmarshalWithNodesAndPrefix(l.prefix, ls.Storage, dst, l.unmarshalOpts...)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling loaded nodes: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package evon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type loaderTestConfig struct {
+	AppInfo struct {
+		Name            string        `env:"NAME"`
+		StartupDuration time.Duration `env:"STARTUP-DURATION"`
+	} `env:"APP-INFO"`
+	LogLevel string `env:"LOG-LEVEL"`
+	Port     uint16 `env:"PORT"`
+}
+
+func TestLoader(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFile(t, filepath.Join(dir, ".env"), `APP_APP-INFO_NAME=evon
+APP_APP-INFO_STARTUP-DURATION=5s
+APP_LOG-LEVEL=info
+APP_PORT=8080`)
+	writeTestFile(t, filepath.Join(dir, ".env.dev"), `APP_LOG-LEVEL=debug
+APP_PORT=8081`)
+	writeTestFile(t, filepath.Join(dir, ".env.local"), `APP_APP-INFO_STARTUP-DURATION=1s`)
+
+	t.Setenv("APP_PORT", "9090")
+
+	l := NewLoader(
+		WithPrefix("app"),
+		WithProfile(dir, "dev"),
+		WithProcessEnv(),
+	)
+
+	var cfg loaderTestConfig
+	err := l.Load(context.Background(), &cfg)
+	require.NoError(t, err)
+
+	expected := loaderTestConfig{}
+	expected.AppInfo.Name = "evon"
+	expected.AppInfo.StartupDuration = time.Second
+	expected.LogLevel = "debug"
+	expected.Port = 9090
+
+	require.Equal(t, expected, cfg)
+
+	ls, err := l.LoadNodes(context.Background())
+	require.NoError(t, err)
+
+	origin, ok := ls.Origin("APP_PORT")
+	require.True(t, ok)
+	require.Equal(t, processEnvLayer, origin)
+
+	origin, ok = ls.Origin("APP_LOG-LEVEL")
+	require.True(t, ok)
+	require.Equal(t, filepath.Join(dir, ".env.dev"), origin)
+}
+
+func TestLoaderProcessEnvPrefix(t *testing.T) {
+	t.Setenv("APP_PORT", "9090")
+	t.Setenv("OTHER_PORT", "8080")
+
+	// prefix is applied regardless of order of options
+	ls, err := NewLoader(
+		WithProcessEnv(),
+		WithPrefix("app"),
+	).LoadNodes(context.Background())
+	require.NoError(t, err)
+
+	require.Equal(t, "9090", ls.Storage["APP_PORT"].Value)
+	require.Nil(t, ls.Storage["OTHER_PORT"])
+}
+
+func TestLoaderMissingFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	var cfg loaderTestConfig
+	err := NewLoader(
+		WithOptionalFile(filepath.Join(dir, ".env.optional")),
+	).Load(context.Background(), &cfg)
+	require.NoError(t, err)
+
+	err = NewLoader(
+		WithFile(filepath.Join(dir, ".env.mandatory")),
+	).Load(context.Background(), &cfg)
+	require.ErrorIs(t, err, ErrMissingSource)
+	require.ErrorContains(t, err, ".env.mandatory")
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	err := os.WriteFile(path, []byte(content), 0o600)
+	require.NoError(t, err)
+}
+
+func TestLoaderInterpolationWithFileIndirection(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "db_pwd"), "secret\n")
+
+	type config struct {
+		DBURL string `env:"DB_URL"`
+	}
+
+	var cfg config
+	err := NewLoader(
+		WithSource(MapSource(map[string]string{
+			"DB_PWD_FILE": filepath.Join(dir, "db_pwd"),
+			"DB_URL":      "postgres://u:${DB_PWD}@h",
+		})),
+		WithInterpolation(),
+		WithUnmarshalOpts(WithFileIndirection("", dir)),
+	).Load(context.Background(), &cfg)
+	require.NoError(t, err)
+	require.Equal(t, "postgres://u:secret@h", cfg.DBURL)
+
+	err = NewLoader(
+		WithSource(MapSource(map[string]string{
+			"DB_PWD_FILE": filepath.Join(dir, "missing"),
+			"DB_URL":      "postgres://u:${DB_PWD}@h",
+		})),
+		WithInterpolation(),
+		WithUnmarshalOpts(WithFileIndirection("")),
+	).Load(context.Background(), &cfg)
+	require.ErrorContains(t, err, "error reading DB_PWD_FILE")
+}
@@ -1,12 +1,33 @@
 package evon
 
 import (
+	"encoding"
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
 	"time"
 )
 
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// newExtractError returns *UnsupportedTypeError of extract functions
+// with path of node e.g. for bool value of int field.
+// Other errors are wrapped into *UnmarshalTypeError
+func newExtractError(src *Node, tp reflect.Type, err error) error {
+	var ute *UnsupportedTypeError
+	if errors.As(err, &ute) {
+		ute.Path = src.Name
+		return ute
+	}
+
+	return newUnmarshalTypeError(src, tp, err)
+}
+
 func extractString(v reflect.Value) string {
 	switch v.Kind() {
 	case reflect.String:
@@ -20,90 +41,287 @@ func extractString(v reflect.Value) string {
 		return fmt.Sprint(v.Interface())
 	}
 }
-func mapString(target reflect.Value) NodeMappingFunc {
-	return func(src *Node) error {
-		target.SetString(extractString(reflect.ValueOf(src.Value)))
-		return nil
-	}
+func mapString(target reflect.Value, src *Node) error {
+	target.SetString(extractString(reflect.ValueOf(src.Value)))
+	return nil
 }
 
-func extractInt(v reflect.Value) int64 {
+func extractInt(v reflect.Value) (int64, error) {
 	switch v.Kind() {
 	case reflect.String:
 		str := v.String()
-		d, _ := strconv.ParseInt(str, 10, 64)
-		return d
-	case reflect.Int:
-		return v.Int()
-	case reflect.Uint:
-		return int64(v.Uint())
+		if str == "" {
+			return 0, nil
+		}
+		return strconv.ParseInt(str, 10, 64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(v.Uint()), nil
+	case reflect.Invalid:
+		return 0, nil
 	default:
-		return 0
+		return 0, &UnsupportedTypeError{Kind: v.Kind()}
 	}
 }
-func mapInt(target reflect.Value) NodeMappingFunc {
-	return func(src *Node) error {
-		target.SetInt(extractInt(reflect.ValueOf(src.Value)))
-		return nil
+func mapInt(target reflect.Value, src *Node) error {
+	i, err := extractInt(reflect.ValueOf(src.Value))
+	if err != nil {
+		return newExtractError(src, target.Type(), err)
+	}
+
+	if target.OverflowInt(i) {
+		return newUnmarshalTypeError(src, target.Type(), errOverflow)
 	}
+
+	target.SetInt(i)
+	return nil
 }
 
-func extractUint(v reflect.Value) uint64 {
+func extractUint(v reflect.Value) (uint64, error) {
 	kind := v.Kind()
 	switch kind {
 	case reflect.String:
 		str := v.String()
-		d, _ := strconv.ParseUint(str, 10, 64)
-		return d
-	case reflect.Int, reflect.Int16, reflect.Int32, reflect.Int64:
-		return uint64(v.Int())
+		if str == "" {
+			return 0, nil
+		}
+		return strconv.ParseUint(str, 10, 64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Int() < 0 {
+			return 0, errOverflow
+		}
+		return uint64(v.Int()), nil
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return v.Uint()
+		return v.Uint(), nil
+	case reflect.Invalid:
+		return 0, nil
 	default:
-		return 0
+		return 0, &UnsupportedTypeError{Kind: v.Kind()}
 	}
 }
-func mapUint(target reflect.Value) NodeMappingFunc {
-	return func(src *Node) error {
-		target.SetUint(extractUint(reflect.ValueOf(src.Value)))
-		return nil
+func mapUint(target reflect.Value, src *Node) error {
+	u, err := extractUint(reflect.ValueOf(src.Value))
+	if err != nil {
+		return newExtractError(src, target.Type(), err)
+	}
+
+	if target.OverflowUint(u) {
+		return newUnmarshalTypeError(src, target.Type(), errOverflow)
 	}
+
+	target.SetUint(u)
+	return nil
 }
 
-func extractDuration(v reflect.Value) int64 {
+func extractDuration(v reflect.Value) (int64, error) {
 	k := v.Kind()
 	switch k {
 	case reflect.String:
 		str := v.String()
-		d, _ := time.ParseDuration(str)
-		return int64(d)
-	case reflect.Int, reflect.Int8, reflect.Int32, reflect.Int64:
-		return v.Int()
+		if str == "" {
+			return 0, nil
+		}
+		d, err := time.ParseDuration(str)
+		return int64(d), err
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Invalid:
+		return 0, nil
 	default:
-		return 0
+		return 0, &UnsupportedTypeError{Kind: v.Kind()}
 	}
 }
-func mapDuration(target reflect.Value) NodeMappingFunc {
-	return func(src *Node) error {
-		target.SetInt(extractDuration(reflect.ValueOf(src.Value)))
-		return nil
+func mapDuration(target reflect.Value, src *Node) error {
+	d, err := extractDuration(reflect.ValueOf(src.Value))
+	if err != nil {
+		return newExtractError(src, target.Type(), err)
 	}
+
+	target.SetInt(d)
+	return nil
 }
 
-func extractBool(v reflect.Value) bool {
+func extractBool(v reflect.Value) (bool, error) {
 	switch v.Kind() {
 	case reflect.Bool:
-		return v.Bool()
+		return v.Bool(), nil
 	case reflect.String:
-		b, _ := strconv.ParseBool(v.String())
-		return b
+		str := v.String()
+		if str == "" {
+			return false, nil
+		}
+		return strconv.ParseBool(str)
+	case reflect.Invalid:
+		return false, nil
 	default:
-		return false
+		return false, &UnsupportedTypeError{Kind: v.Kind()}
 	}
 }
-func mapBool(target reflect.Value) NodeMappingFunc {
+func mapBool(target reflect.Value, src *Node) error {
+	b, err := extractBool(reflect.ValueOf(src.Value))
+	if err != nil {
+		return newExtractError(src, target.Type(), err)
+	}
+
+	target.SetBool(b)
+	return nil
+}
+
+func extractFloat(v reflect.Value) (float64, error) {
+	switch v.Kind() {
+	case reflect.String:
+		str := v.String()
+		if str == "" {
+			return 0, nil
+		}
+		return strconv.ParseFloat(str, 64)
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Invalid:
+		return 0, nil
+	default:
+		return 0, &UnsupportedTypeError{Kind: v.Kind()}
+	}
+}
+func mapFloat(target reflect.Value, src *Node) error {
+	f, err := extractFloat(reflect.ValueOf(src.Value))
+	if err != nil {
+		return newExtractError(src, target.Type(), err)
+	}
+
+	if target.OverflowFloat(f) {
+		return newUnmarshalTypeError(src, target.Type(), errOverflow)
+	}
+
+	target.SetFloat(f)
+	return nil
+}
+
+func mapTime(target reflect.Value, src *Node) error {
+	switch v := src.Value.(type) {
+	case time.Time:
+		target.Set(reflect.ValueOf(v))
+	case string:
+		if v == "" {
+			return nil
+		}
+
+		t := tryParseTime(v)
+		if t == nil {
+			return newUnmarshalTypeError(src, target.Type(), nil)
+		}
+		target.Set(reflect.ValueOf(*t))
+	}
+	return nil
+}
+
+func mapTextUnmarshaler(target reflect.Value, src *Node) error {
+	tu := target.Addr().Interface().(encoding.TextUnmarshaler)
+
+	err := tu.UnmarshalText([]byte(extractString(reflect.ValueOf(src.Value))))
+	if err != nil {
+		return newUnmarshalTypeError(src, target.Type(), err)
+	}
+
+	return nil
+}
+
+// mapCommaSlice fills slice of basic types from single value
+// e.g. "1,2,3" -> []int{1, 2, 3}
+func mapCommaSlice(target reflect.Value, src *Node) error {
+	str := extractString(reflect.ValueOf(src.Value))
+	if str == "" {
+		return nil
+	}
+
+	parts := strings.Split(str, sliceSeparator)
+	out := reflect.MakeSlice(target.Type(), 0, len(parts))
+	for _, part := range parts {
+		elem := reflect.New(target.Type().Elem()).Elem()
+
+		mapFunc := getValueMappingFunc(elem)
+		if mapFunc == nil {
+			return &UnsupportedTypeError{Path: src.Name, Kind: elem.Kind()}
+		}
+
+		err := mapFunc(&Node{Name: src.Name, Value: part})
+		if err != nil {
+			return err
+		}
+
+		out = reflect.Append(out, elem)
+	}
+
+	target.Set(out)
+	return nil
+}
+
+func mapSlice(target reflect.Value, src *Node) error {
+	u := &defaultSliceUnmarshaller{
+		ref: target.Addr(),
+	}
+
+	return u.UnmarshalEnv(src)
+}
+
+func mapCustomUnmarshaler(target reflect.Value, src *Node) error {
+	return target.Addr().Interface().(CustomUnmarshaler).UnmarshalEnv(src)
+}
+
+// getValueMappingFunc returns mapping func for value types
+// that are stored in a single node: basic types, time and text unmarshalers
+func getValueMappingFunc(target reflect.Value) NodeMappingFunc {
+	if !target.IsValid() {
+		return nil
+	}
+
+	convert := getValueConverter(target.Type())
+	if convert == nil {
+		return nil
+	}
+
+	if !target.CanAddr() && target.Type() != timeType && reflect.PointerTo(target.Type()).Implements(textUnmarshalerType) {
+		return nil
+	}
+
 	return func(src *Node) error {
-		target.SetBool(extractBool(reflect.ValueOf(src.Value)))
+		return convert(target, src)
+	}
+}
+
+// getValueConverter returns converter for value types
+// that are stored in a single node: basic types, time and text unmarshalers
+func getValueConverter(tp reflect.Type) valueConverter {
+	if tp == timeType {
+		return mapTime
+	}
+
+	if reflect.PointerTo(tp).Implements(textUnmarshalerType) {
+		return mapTextUnmarshaler
+	}
+
+	switch tp.Kind() {
+	case reflect.String:
+		return mapString
+	case reflect.Bool:
+		return mapBool
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if tp.Name() == "Duration" {
+			return mapDuration
+		}
+		return mapInt
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return mapUint
+	case reflect.Float32, reflect.Float64:
+		return mapFloat
+	default:
 		return nil
 	}
 }
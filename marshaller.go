@@ -2,27 +2,15 @@ package evon
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"reflect"
 	"strings"
 	"time"
-	"unicode"
 
 	"go.redsock.ru/rerrors"
-	"go.redsock.ru/toolbox"
 )
 
-const (
-	envTag         = "env"
-	evonTag        = "evon"
-	sliceSeparator = ","
-	tagOmitempty   = "omitempty"
-)
-
-var (
-	ErrUnsupportedType = errors.New("")
-)
+const sliceSeparator = ","
 
 type customMarshaller interface {
 	MarshalEnv(prefix string) ([]*Node, error)
@@ -49,16 +37,28 @@ func MarshalEnvWithPrefix(prefix string, in any) (*Node, error) {
 
 func Marshal(nodes []*Node) []byte {
 	b := bytes.NewBuffer(nil)
+	walkValuedNodes(nodes, func(node *Node) {
+		writeEnvLine(b, node.Name, node.Value)
+	})
+	return b.Bytes()
+}
+
+// walkValuedNodes calls f for every node with value in order of marshalling.
+// Node's value goes before values of its inner nodes
+func walkValuedNodes(nodes []*Node, f func(node *Node)) {
 	for _, node := range nodes {
-		if node.Value != nil && len(node.InnerNodes) == 0 {
-			b.WriteString(node.Name)
-			b.WriteByte('=')
-			b.WriteString(fmt.Sprint(node.Value))
-			b.WriteByte('\n')
+		if node.Value != nil {
+			f(node)
 		}
-		b.Write(Marshal(node.InnerNodes))
+		walkValuedNodes(node.InnerNodes, f)
 	}
-	return b.Bytes()
+}
+
+func writeEnvLine(b *bytes.Buffer, name string, value any) {
+	b.WriteString(name)
+	b.WriteByte('=')
+	b.WriteString(valueToString(value))
+	b.WriteByte('\n')
 }
 
 func (m marshaller) marshal(prefix string, ref reflect.Value) (n *Node, err error) {
@@ -122,12 +122,12 @@ func marshalSlice(prefix string, ref reflect.Value) (*Node, error) {
 					val = ref.Index(i).Interface()
 					m, ok := val.(customMarshaller)
 					if !ok {
-						return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, tp.String())
+						return nil, &UnsupportedTypeError{Path: prefix, Kind: tp}
 					}
 
 					node, err := m.MarshalEnv(prefix)
 					if err != nil {
-						return nil, fmt.Errorf("error marshalling slice element: %w", err)
+						return nil, fmt.Errorf("error marshalling slice element of %s: %w", prefix, err)
 					}
 
 					out = append(out, node...)
@@ -170,13 +170,13 @@ func marshalSlice(prefix string, ref reflect.Value) (*Node, error) {
 		}
 		return node, nil
 	default:
-		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, tp.String())
+		return nil, &UnsupportedTypeError{Path: prefix, Kind: tp}
 	}
 
 	var err error
 	n.InnerNodes, err = marshaller(prefix, ref)
 	if err != nil {
-		return nil, fmt.Errorf("%w:%s", err, "error marshalling")
+		return nil, fmt.Errorf("error marshalling slice %s: %w", prefix, err)
 	}
 	return n, nil
 }
@@ -193,7 +193,7 @@ func marshalMap(prefix string, ref reflect.Value) (*Node, error) {
 
 	innerNodes, err := cm.MarshalEnv(prefix)
 	if err != nil {
-		return nil, fmt.Errorf("%w:%s", err, "error marshalling env")
+		return nil, fmt.Errorf("error marshalling map %s: %w", prefix, err)
 	}
 	return &Node{
 		Name:       prefix,
@@ -227,41 +227,13 @@ func marshalStruct(prefix string, ref reflect.Value) (*Node, error) {
 		n.Value = formatTime(t)
 		return n, nil
 	}
-	for i := 0; i < ref.NumField(); i++ {
-		field := ref.Type().Field(i)
-		t := toolbox.Coalesce(
-			field.Tag.Get(evonTag),
-			field.Tag.Get(envTag))
-		tags := strings.Split(t, sliceSeparator)
-
-		var skip, omitempty bool
-		var tag string
-
-		for _, t = range tags {
-			if t == "-" {
-				skip = true
-				break
-			}
-			if t == tagOmitempty {
-				omitempty = true
-				continue
-			}
-			tag = t
-		}
-
-		if skip {
-			continue
-		}
-
-		value := ref.Field(i)
-		if value.IsZero() && omitempty {
+	for _, f := range structPlanOf(ref.Type()).fields {
+		value := ref.Field(f.index)
+		if value.IsZero() && f.tag.Omitempty {
 			continue
 		}
 
-		if tag == "" {
-			tag = splitToKebab(field.Name)
-		}
-
+		tag := f.name
 		if prefix != "" && tag != "" {
 			tag = "_" + tag
 		}
@@ -272,6 +244,7 @@ func marshalStruct(prefix string, ref reflect.Value) (*Node, error) {
 			return nil, err
 		}
 		if node != nil {
+			node.Secret = node.Secret || f.tag.Secret
 			n.InnerNodes = append(n.InnerNodes, node)
 		}
 	}
@@ -279,20 +252,6 @@ func marshalStruct(prefix string, ref reflect.Value) (*Node, error) {
 	return n, nil
 }
 
-func splitToKebab(in string) string {
-	inR := []rune(in)
-	out := make([]rune, 0, len(inR)+2)
-	for idx, r := range inR {
-		if unicode.IsUpper(r) && idx != 0 {
-			out = append(out, '-')
-		}
-
-		out = append(out, r)
-	}
-
-	return string(out)
-}
-
 type defaultSliceMarshaller struct {
 	sliceRef reflect.Value
 }
@@ -345,6 +304,20 @@ func nameToEvonName(name string) string {
 	return strings.Replace(name, "_", "-", -1)
 }
 
+// valueToString returns env representation of node's value
+func valueToString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return formatTime(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
 func formatTime(t time.Time) string {
 	if t.Nanosecond() != 0 {
 		return t.Format(time.RFC3339Nano)
@@ -147,3 +147,36 @@ func TestMarshalFromYaml(t *testing.T) {
 }
 
 //
+
+func TestMarshalNodeWithValueAndInnerNodes(t *testing.T) {
+	t.Parallel()
+
+	input := []byte("A=1\nA_B=2\n")
+
+	ns := ParseToNodes(input)
+	require.Equal(t, string(input), string(Marshal(ns[""].InnerNodes)))
+}
+
+func TestMarshalSecretTag(t *testing.T) {
+	t.Parallel()
+
+	type Tokens struct {
+		Github string `evon:"GITHUB"`
+	}
+
+	type Config struct {
+		Host     string  `evon:"HOST"`
+		Password *string `evon:"PASSWORD,omitempty,secret"`
+		Tokens   Tokens  `evon:"TOKENS,secret"`
+	}
+
+	pwd := "pwd"
+	n, err := MarshalEnv(Config{Host: "localhost", Password: &pwd})
+	require.NoError(t, err)
+
+	ns := NodesToStorage(n)
+	require.False(t, ns["HOST"].Secret)
+	require.True(t, ns["PASSWORD"].Secret)
+	require.True(t, ns["TOKENS"].Secret)
+	require.False(t, ns["TOKENS_GITHUB"].Secret)
+}
@@ -0,0 +1,171 @@
+package evon
+
+import (
+	"bytes"
+	"sort"
+)
+
+const (
+	conflictOursMarker   = "<<<<<<< ours"
+	conflictBaseMarker   = "||||||| base"
+	conflictSplitMarker  = "======="
+	conflictTheirsMarker = ">>>>>>> theirs"
+)
+
+// Conflict describes node that was changed differently in ours and theirs trees.
+// Nil value means that node doesn't exist in corresponding tree
+type Conflict struct {
+	Path   string
+	Base   any
+	Ours   any
+	Theirs any
+}
+
+// Merge3 merges changes made in ours and theirs relatively to common base.
+// Non overlapping changes are merged automatically.
+// If node was changed in both trees with different values - it goes to conflicts
+// and merged tree keeps value from ours
+func Merge3(base, ours, theirs *Node) (*Node, []Conflict) {
+	baseValues := valuesByPath(base)
+	oursValues := valuesByPath(ours)
+	theirsValues := valuesByPath(theirs)
+
+	paths := make(map[string]struct{}, len(oursValues)+len(theirsValues))
+	for _, values := range []map[string]any{baseValues, oursValues, theirsValues} {
+		for path := range values {
+			paths[path] = struct{}{}
+		}
+	}
+
+	sortedPaths := make([]string, 0, len(paths))
+	for path := range paths {
+		sortedPaths = append(sortedPaths, path)
+	}
+	sort.Strings(sortedPaths)
+
+	merged := NodeStorage{}
+	merged[""] = &Node{}
+
+	var conflicts []Conflict
+	for _, path := range sortedPaths {
+		b, inBase := baseValues[path]
+		o, inOurs := oursValues[path]
+		t, inTheirs := theirsValues[path]
+
+		var value any
+		var exists bool
+
+		switch {
+		case sameValue(o, inOurs, t, inTheirs):
+			value, exists = o, inOurs
+		case sameValue(o, inOurs, b, inBase):
+			value, exists = t, inTheirs
+		case sameValue(t, inTheirs, b, inBase):
+			value, exists = o, inOurs
+		default:
+			conflicts = append(conflicts, Conflict{
+				Path:   path,
+				Base:   b,
+				Ours:   o,
+				Theirs: t,
+			})
+			value, exists = o, inOurs
+		}
+
+		if exists {
+			merged.AddNode(&Node{
+				Name:  path,
+				Value: value,
+			})
+		}
+	}
+
+	return merged[""], conflicts
+}
+
+// MarshalWithConflicts works like Marshal but renders
+// conflicting nodes with git-like conflict markers
+// e.g.
+//
+//	<<<<<<< ours
+//	DB_PORT=5433
+//	||||||| base
+//	DB_PORT=5432
+//	=======
+//	DB_PORT=6432
+//	>>>>>>> theirs
+func MarshalWithConflicts(merged *Node, conflicts []Conflict) []byte {
+	conflictsByPath := make(map[string]Conflict, len(conflicts))
+	for _, c := range conflicts {
+		conflictsByPath[c.Path] = c
+	}
+
+	b := bytes.NewBuffer(nil)
+
+	if merged != nil {
+		walkValuedNodes(merged.InnerNodes, func(node *Node) {
+			c, ok := conflictsByPath[node.Name]
+			if ok {
+				writeConflict(b, c)
+				delete(conflictsByPath, node.Name)
+			} else {
+				writeEnvLine(b, node.Name, node.Value)
+			}
+		})
+	}
+
+	// conflicts for nodes deleted in ours are not presented in merged tree
+	for _, c := range conflicts {
+		if _, ok := conflictsByPath[c.Path]; ok {
+			writeConflict(b, c)
+		}
+	}
+
+	return b.Bytes()
+}
+
+func writeConflict(b *bytes.Buffer, c Conflict) {
+	b.WriteString(conflictOursMarker)
+	b.WriteByte('\n')
+	if c.Ours != nil {
+		writeEnvLine(b, c.Path, c.Ours)
+	}
+
+	b.WriteString(conflictBaseMarker)
+	b.WriteByte('\n')
+	if c.Base != nil {
+		writeEnvLine(b, c.Path, c.Base)
+	}
+
+	b.WriteString(conflictSplitMarker)
+	b.WriteByte('\n')
+	if c.Theirs != nil {
+		writeEnvLine(b, c.Path, c.Theirs)
+	}
+
+	b.WriteString(conflictTheirsMarker)
+	b.WriteByte('\n')
+}
+
+func valuesByPath(n *Node) map[string]any {
+	out := map[string]any{}
+	if n == nil {
+		return out
+	}
+
+	for path, node := range NodesToStorage(n) {
+		if node.Value != nil {
+			out[path] = node.Value
+		}
+	}
+
+	return out
+}
+
+func sameValue(a any, aExists bool, b any, bExists bool) bool {
+	if aExists != bExists {
+		return false
+	}
+
+	return !aExists || valuesEqual(a, b)
+}
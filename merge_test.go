@@ -0,0 +1,99 @@
+package evon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Merge3(t *testing.T) {
+	t.Parallel()
+
+	base := ParseToNodes([]byte(`DB_HOST=localhost
+DB_PORT=5432
+DB_USER=admin
+CACHE_TTL=10s
+`))[""]
+
+	ours := ParseToNodes([]byte(`DB_HOST=localhost
+DB_PORT=5433
+DB_USER=admin
+CACHE_TTL=10s
+CACHE_SIZE=100
+`))[""]
+
+	theirs := ParseToNodes([]byte(`DB_HOST=db
+DB_PORT=6432
+CACHE_TTL=10s
+`))[""]
+
+	merged, conflicts := Merge3(base, ours, theirs)
+
+	require.Equal(t, []Conflict{
+		{
+			Path:   "DB_PORT",
+			Base:   "5432",
+			Ours:   "5433",
+			Theirs: "6432",
+		},
+	}, conflicts)
+
+	require.Equal(t, `CACHE_SIZE=100
+CACHE_TTL=10s
+DB_HOST=db
+DB_PORT=5433
+`, string(Marshal(merged.InnerNodes)))
+
+	require.Equal(t, `CACHE_SIZE=100
+CACHE_TTL=10s
+DB_HOST=db
+<<<<<<< ours
+DB_PORT=5433
+||||||| base
+DB_PORT=5432
+=======
+DB_PORT=6432
+>>>>>>> theirs
+`, string(MarshalWithConflicts(merged, conflicts)))
+}
+
+func Test_Merge3DeletedInOurs(t *testing.T) {
+	t.Parallel()
+
+	base := ParseToNodes([]byte(`A=1`))[""]
+	ours := &Node{}
+	theirs := ParseToNodes([]byte(`A=2`))[""]
+
+	merged, conflicts := Merge3(base, ours, theirs)
+	require.Empty(t, merged.InnerNodes)
+	require.Equal(t, []Conflict{{Path: "A", Base: "1", Theirs: "2"}}, conflicts)
+
+	require.Equal(t, `<<<<<<< ours
+||||||| base
+A=1
+=======
+A=2
+>>>>>>> theirs
+`, string(MarshalWithConflicts(merged, conflicts)))
+}
+
+func Test_MarshalWithConflictsNodeWithValueAndInnerNodes(t *testing.T) {
+	t.Parallel()
+
+	input := []byte("A=1\nA_B=2\n")
+	n := ParseToNodes(input)[""]
+
+	require.Equal(t, string(Marshal(n.InnerNodes)), string(MarshalWithConflicts(n, nil)))
+	require.Equal(t, string(input), string(MarshalWithConflicts(n, nil)))
+
+	merged, conflicts := Merge3(n, ParseToNodes([]byte("A=3\nA_B=2\n"))[""], ParseToNodes([]byte("A=4\nA_B=2\n"))[""])
+	require.Equal(t, `<<<<<<< ours
+A=3
+||||||| base
+A=1
+=======
+A=4
+>>>>>>> theirs
+A_B=2
+`, string(MarshalWithConflicts(merged, conflicts)))
+}
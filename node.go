@@ -33,44 +33,109 @@ type Node struct {
 	Name       string
 	Value      any
 	InnerNodes []*Node
+	// Secret is set on nodes of fields tagged with "secret".
+	// Inner nodes of secret node are considered secret too
+	Secret bool
 }
 
 type NodeStorage map[string]*Node
 
+// ParseToNodes parses env file into nodes.
+// Every line is NAME=VALUE where value is the rest of line after the first "=" as is.
+// Lines are parsed by the following rules:
+//   - trailing "\r" are trimmed, so files with CRLF line endings give the same values
+//   - blank lines and lines starting with "#" (after spaces and tabs) are comments
+//   - lines without "=" and lines with empty name are skipped
+//
+// Input is copied into single string once: names and values
+// of nodes are its substrings, intermediate nodes' names are prefixes of keys
 func ParseToNodes(bytes []byte) NodeStorage {
-	nodesMap := NodeStorage{}
-
-	name := ""
-	var value any
-
-	start := 0
-	idx := 0
-	for idx = range bytes {
-		switch bytes[idx] {
-		case '=':
-			name = string(bytes[start:idx])
-			start = idx + 1
-		case '\n':
-			value = string(bytes[start:idx])
-			start = idx + 1
-			nodesMap.AddNode(&Node{
-				Name:  name,
-				Value: value,
-			})
-		}
+	src := string(bytes)
+
+	lines := strings.Count(src, "\n") + 1
+	nodesMap := make(NodeStorage, lines+lines/2)
+	b := nodeBuilder{
+		storage: nodesMap,
+		arena:   &nodeArena{},
 	}
-	if bytes[idx] != '\n' {
-		value = string(bytes[start : idx+1])
-		start = idx + 1
-		nodesMap.AddNode(&Node{
-			Name:  name,
-			Value: value,
-		})
+
+	for src != "" {
+		line := src
+		idx := strings.IndexByte(src, '\n')
+		if idx == -1 {
+			src = ""
+		} else {
+			line, src = src[:idx], src[idx+1:]
+		}
+
+		name, value, ok := parseEnvLine(line)
+		if !ok {
+			continue
+		}
+
+		node := b.arena.new()
+		node.Name = name
+		node.Value = value
+		b.add(node)
 	}
 
 	return nodesMap
 }
 
+// parseEnvLine splits line of env file into name and value.
+// ok is false for comments, blank lines and lines
+// which can't be variables (without "=" or with empty name)
+func parseEnvLine(line string) (name, value string, ok bool) {
+	line = strings.TrimRight(line, "\r")
+	if isCommentLine(line) {
+		return "", "", false
+	}
+
+	eq := strings.IndexByte(line, '=')
+	if eq <= 0 {
+		return "", "", false
+	}
+
+	return line[:eq], line[eq+1:], true
+}
+
+// isCommentLine reports if line is blank or starts with "#"
+func isCommentLine(line string) bool {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case ' ', '\t':
+			continue
+		case '#':
+			return true
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+const nodeArenaChunk = 256
+
+// nodeArena allocates nodes in chunks
+type nodeArena struct {
+	chunk []Node
+}
+
+func (a *nodeArena) new() *Node {
+	if a == nil {
+		return &Node{}
+	}
+
+	if len(a.chunk) == 0 {
+		a.chunk = make([]Node, nodeArenaChunk)
+	}
+
+	n := &a.chunk[0]
+	a.chunk = a.chunk[1:]
+	return n
+}
+
 func NodesToStorage(n *Node) NodeStorage {
 	ns := NodeStorage{}
 	if n == nil {
@@ -87,52 +152,93 @@ func NodesToStorage(n *Node) NodeStorage {
 }
 
 func (s NodeStorage) AddNode(node *Node) {
+	b := nodeBuilder{storage: s}
+	b.add(node)
+}
+
+// nodeBuilder puts nodes into storage and creates missing parents.
+// Parents are looked up by prefixes of node's name
+// e.g. for "A_B_C" parents are "A_B", "A" and root "".
+// Parents of previous node are reused, so only new segments
+// of grouped keys are looked up in storage
+type nodeBuilder struct {
+	storage NodeStorage
+	arena   *nodeArena
+	// parents of previous node starting from root
+	parents []*Node
+}
+
+func (b *nodeBuilder) add(node *Node) {
+	s := b.storage
 	rootName := node.Name
 
-	nameParts := strings.Split(rootName, "_")
+	depth := len(b.parents)
+	for depth > 1 && !isParentName(b.parents[depth-1].Name, rootName) {
+		depth--
+	}
+	b.parents = b.parents[:depth]
+
+	if depth == 0 {
+		root := s[""]
+		if root == nil {
+			root = b.arena.new()
+			s[""] = root
+		}
+		b.parents = append(b.parents, root)
+		depth = 1
+	}
+
+	lastNode := b.parents[depth-1]
 
-	nodePath := ""
-	lastNode := s[nodePath]
-	if lastNode == nil {
-		lastNode = &Node{}
-		s[nodePath] = lastNode
+	from := 0
+	if depth > 1 {
+		from = len(lastNode.Name) + len(ObjectSplitter)
 	}
 
-	for _, namePart := range nameParts[:len(nameParts)-1] {
-		if nodePath != "" {
-			nodePath += "_"
+	for {
+		idx := strings.Index(rootName[from:], ObjectSplitter)
+		if idx == -1 {
+			break
 		}
-		nodePath = nodePath + namePart
+
+		nodePath := rootName[:from+idx]
+		from += idx + len(ObjectSplitter)
 
 		nextNode := s[nodePath]
 		if nextNode == nil {
-			nextNode = &Node{
-				Name: nodePath,
-			}
-			if lastNode != nil {
-				lastNode.InnerNodes = append(lastNode.InnerNodes, nextNode)
-			}
+			nextNode = b.arena.new()
+			nextNode.Name = nodePath
+			lastNode.InnerNodes = append(lastNode.InnerNodes, nextNode)
 			s[nodePath] = nextNode
 		}
 
+		b.parents = append(b.parents, nextNode)
 		lastNode = nextNode
 	}
 
-	var existingNode *Node
-	for _, n := range lastNode.InnerNodes {
-		if n.Name == node.Name {
-			existingNode = n
-			break
-		}
+	// Nodes passed from outside can already be in parent's tree
+	// without being in storage (e.g. in NodesToStorage)
+	existingNode := s[node.Name]
+	if existingNode == nil && b.arena == nil {
+		existingNode = findInnerNode(lastNode, node.Name)
 	}
-	if existingNode == nil {
+
+	switch {
+	case existingNode == node:
+		s[node.Name] = node
+	case existingNode == nil, existingNode == lastNode:
+		// root itself is replaced by node with empty name
+		if node.Name == "" {
+			b.parents = b.parents[:0]
+		}
+
 		lastNode.InnerNodes = append(lastNode.InnerNodes, node)
-	} else {
+		s[node.Name] = node
+	default:
 		existingNode.Value = node.Value
+		s[node.Name] = existingNode
 	}
 
-	s[node.Name] = node
-
 	for _, n := range node.InnerNodes {
 		if n == nil {
 			continue
@@ -142,7 +248,65 @@ func (s NodeStorage) AddNode(node *Node) {
 			n.Name = rootName + "_" + n.Name
 		}
 
-		s.AddNode(n)
+		b.add(n)
+	}
+}
+
+func isParentName(parent, name string) bool {
+	return len(name) > len(parent) &&
+		strings.HasPrefix(name, parent) &&
+		strings.HasPrefix(name[len(parent):], ObjectSplitter)
+}
+
+func findInnerNode(parent *Node, name string) *Node {
+	for _, n := range parent.InnerNodes {
+		if n.Name == name {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// RemoveNode removes node by its name from storage and from parent's inner nodes.
+// Parents left without value and inner nodes are removed as well
+func (s NodeStorage) RemoveNode(name string) {
+	node, ok := s[name]
+	if !ok || name == "" {
+		return
+	}
+
+	delete(s, name)
+	for _, n := range node.InnerNodes {
+		s.removeSubTree(n)
+	}
+
+	parentName := ""
+	if idx := strings.LastIndex(name, ObjectSplitter); idx != -1 {
+		parentName = name[:idx]
+	}
+
+	parent := s[parentName]
+	if parent == nil {
+		return
+	}
+
+	for idx, n := range parent.InnerNodes {
+		if n.Name == name {
+			parent.InnerNodes = append(parent.InnerNodes[:idx], parent.InnerNodes[idx+1:]...)
+			break
+		}
+	}
+
+	if len(parent.InnerNodes) == 0 && parent.Value == nil {
+		s.RemoveNode(parentName)
+	}
+}
+
+func (s NodeStorage) removeSubTree(node *Node) {
+	delete(s, node.Name)
+	for _, n := range node.InnerNodes {
+		s.removeSubTree(n)
 	}
 }
 
@@ -161,3 +325,26 @@ func (e *Node) RemovePrefix(prefix string) {
 		n.RemovePrefix(prefix)
 	}
 }
+
+// Clone returns deep copy of node and its inner nodes.
+// Values are copied as is
+func (e *Node) Clone() *Node {
+	if e == nil {
+		return nil
+	}
+
+	c := &Node{
+		Name:   e.Name,
+		Value:  e.Value,
+		Secret: e.Secret,
+	}
+
+	if len(e.InnerNodes) != 0 {
+		c.InnerNodes = make([]*Node, len(e.InnerNodes))
+		for i, n := range e.InnerNodes {
+			c.InnerNodes[i] = n.Clone()
+		}
+	}
+
+	return c
+}
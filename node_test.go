@@ -0,0 +1,244 @@
+package evon
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseToNodes(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected map[string]any
+	}
+
+	tests := map[string]testCase{
+		"EMPTY": {
+			input:    "",
+			expected: map[string]any{},
+		},
+		"NO_TRAILING_NEW_LINE": {
+			input: "A=1\nB=2",
+			expected: map[string]any{
+				"A": "1",
+				"B": "2",
+			},
+		},
+		"DUPLICATE_KEY": {
+			input: "A=1\nA=2\n",
+			expected: map[string]any{
+				"A": "2",
+			},
+		},
+		"EMPTY_VALUE": {
+			input: "A=\n",
+			expected: map[string]any{
+				"A": "",
+			},
+		},
+		"SKIPPED_LINES": {
+			input: "# header\r\n\nA=1\r\nGARBAGE\n=2\nB=2",
+			expected: map[string]any{
+				"A": "1",
+				"B": "2",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			values := map[string]any{}
+			for key, n := range ParseToNodes([]byte(tc.input)) {
+				if n.Value != nil {
+					values[key] = n.Value
+				}
+			}
+
+			require.Equal(t, tc.expected, values)
+		})
+	}
+}
+
+func Test_ParseEnvLine(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		line  string
+		name  string
+		value string
+		ok    bool
+	}
+
+	tests := map[string]testCase{
+		"VARIABLE": {
+			line:  "A=1",
+			name:  "A",
+			value: "1",
+			ok:    true,
+		},
+		"EQUALS_IN_VALUE": {
+			line:  "DSN=postgres://u:p@host/db?sslmode=disable",
+			name:  "DSN",
+			value: "postgres://u:p@host/db?sslmode=disable",
+			ok:    true,
+		},
+		"SPACES_ARE_KEPT": {
+			line:  "A= 1 # not comment",
+			name:  "A",
+			value: " 1 # not comment",
+			ok:    true,
+		},
+		"CRLF": {
+			line:  "A=1\r",
+			name:  "A",
+			value: "1",
+			ok:    true,
+		},
+		"TRAILING_CARRIAGE_RETURNS": {
+			line:  "A=1\r\r",
+			name:  "A",
+			value: "1",
+			ok:    true,
+		},
+		"BLANK": {
+			line: " \t\r",
+		},
+		"COMMENT": {
+			line: "# A=1",
+		},
+		"INDENTED_COMMENT": {
+			line: "  \t# A=1",
+		},
+		"WITHOUT_EQUALS": {
+			line: "GARBAGE",
+		},
+		"EMPTY_NAME": {
+			line: "=1",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			n, v, ok := parseEnvLine(tc.line)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.name, n)
+			require.Equal(t, tc.value, v)
+		})
+	}
+}
+
+func Test_ParseToNodesTree(t *testing.T) {
+	t.Parallel()
+
+	ns := ParseToNodes([]byte(`DB_MASTER_HOST=localhost
+DB_MASTER_PORT=5432
+DB_REPLICA_HOST=replica
+`))
+
+	require.Len(t, ns[""].InnerNodes, 1)
+	require.Equal(t, ns["DB"], ns[""].InnerNodes[0])
+	require.Equal(t, []*Node{ns["DB_MASTER"], ns["DB_REPLICA"]}, ns["DB"].InnerNodes)
+	require.Equal(t, []*Node{ns["DB_MASTER_HOST"], ns["DB_MASTER_PORT"]}, ns["DB_MASTER"].InnerNodes)
+	require.Equal(t, "DB_MASTER", ns["DB_MASTER"].Name)
+	require.Nil(t, ns["DB_MASTER"].Value)
+}
+
+func Test_ParseToNodesAllocs(t *testing.T) {
+	const keys = 10_000
+	src := generateFlagsEnv(keys)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		ParseToNodes(src)
+	})
+
+	// value boxing and inner nodes of unique FLAG-i parents per key,
+	// growth of inner nodes of 100 groups and chunks of nodes are shared
+	require.LessOrEqual(t, allocs, float64(keys*2+1_500))
+}
+
+func Test_ParseToNodesDeepAllocs(t *testing.T) {
+	const keys = 10_000
+	src := generateDeepEnv(keys, 20)
+
+	allocs := testing.AllocsPerRun(10, func() {
+		ParseToNodes(src)
+	})
+
+	// parents are shared by every key, so only value is boxed per key
+	require.LessOrEqual(t, allocs, float64(keys+500))
+}
+
+func Test_ParseToNodesLinear(t *testing.T) {
+	small := testing.AllocsPerRun(5, func() {
+		ParseToNodes(generateFlagsEnv(1_000))
+	})
+	large := testing.AllocsPerRun(5, func() {
+		ParseToNodes(generateFlagsEnv(10_000))
+	})
+
+	require.Less(t, large, small*11)
+}
+
+func generateFlagsEnv(keys int) []byte {
+	b := bytes.NewBuffer(nil)
+	for i := range keys {
+		fmt.Fprintf(b, "FEATURE-FLAGS_GROUP-%d_FLAG-%d_ENABLED=true\n", i%100, i)
+	}
+
+	return b.Bytes()
+}
+
+func generateDeepEnv(keys, depth int) []byte {
+	b := bytes.NewBuffer(nil)
+	for i := range keys {
+		fmt.Fprintf(b, "%sKEY-%d=%d\n", strings.Repeat("LEVEL_", depth), i, i)
+	}
+
+	return b.Bytes()
+}
+
+func BenchmarkParseToNodes_10k(b *testing.B) {
+	src := generateFlagsEnv(10_000)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(src)))
+	for range b.N {
+		ParseToNodes(src)
+	}
+}
+
+func BenchmarkParseToNodes_Deep10k(b *testing.B) {
+	for _, depth := range []int{1, 10, 50} {
+		b.Run(fmt.Sprintf("depth_%d", depth), func(b *testing.B) {
+			src := generateDeepEnv(10_000, depth)
+
+			b.ReportAllocs()
+			b.SetBytes(int64(len(src)))
+			for range b.N {
+				ParseToNodes(src)
+			}
+		})
+	}
+}
+
+func TestNodeClone(t *testing.T) {
+	t.Parallel()
+
+	n := ParseToNodes([]byte("A_B=1\nA_C_D=2\n"))[""]
+	c := n.Clone()
+	require.Equal(t, n, c)
+
+	c.InnerNodes[0].RemovePrefix("A")
+	c.InnerNodes[0].InnerNodes[0].Value = "3"
+
+	require.Equal(t, "A_B=1\nA_C_D=2\n", string(Marshal(n.InnerNodes)))
+}
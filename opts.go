@@ -4,12 +4,39 @@ import (
 	"strings"
 )
 
+const (
+	defaultFileSuffix = "_FILE"
+)
+
 type unmarshalOpts struct {
 	keyName func(string) string
+
+	fileSuffix      string
+	fileAllowedDirs []string
+	fileMaxSize     int64
+
+	disallowUnknownKeys bool
+
+	// skipValidation is set for nested unmarshalling
+	// e.g. of slice elements which are validated as a part of root value
+	skipValidation bool
 }
 
 type unmarshalOpt func(o *unmarshalOpts)
 
+func newUnmarshalOpts(opts []unmarshalOpt) unmarshalOpts {
+	o := unmarshalOpts{
+		keyName:     func(s string) string { return s },
+		fileMaxSize: defaultMaxFileSize,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
 func WithSnakeUnmarshal() func(o *unmarshalOpts) {
 	return func(o *unmarshalOpts) {
 		o.keyName = func(s string) string {
@@ -19,3 +46,42 @@ func WithSnakeUnmarshal() func(o *unmarshalOpts) {
 		}
 	}
 }
+
+// WithFileIndirection enables reading values from files
+// e.g. DB_PWD_FILE=/run/secrets/db means "read DB_PWD from /run/secrets/db".
+// File is read only if plain variable (DB_PWD) has no value
+// and is mapped into destination under unmarshal prefix.
+// Default suffix is "_FILE".
+// If allowedDirs are passed, only files inside them can be read
+func WithFileIndirection(suffix string, allowedDirs ...string) func(o *unmarshalOpts) {
+	return func(o *unmarshalOpts) {
+		if suffix == "" {
+			suffix = defaultFileSuffix
+		}
+
+		o.fileSuffix = suffix
+		o.fileAllowedDirs = allowedDirs
+	}
+}
+
+// WithFileIndirectionMaxSize limits size of file read with WithFileIndirection.
+// Default is 1 MiB
+func WithFileIndirectionMaxSize(size int64) func(o *unmarshalOpts) {
+	return func(o *unmarshalOpts) {
+		o.fileMaxSize = size
+	}
+}
+
+func withoutValidation() func(o *unmarshalOpts) {
+	return func(o *unmarshalOpts) {
+		o.skipValidation = true
+	}
+}
+
+// WithDisallowUnknownKeys makes unmarshal return *UnknownKeyError
+// for every variable under prefix that doesn't match any field of destination struct
+func WithDisallowUnknownKeys() func(o *unmarshalOpts) {
+	return func(o *unmarshalOpts) {
+		o.disallowUnknownKeys = true
+	}
+}
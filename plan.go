@@ -0,0 +1,283 @@
+package evon
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"go.redsock.ru/evon/internal/structtag"
+)
+
+var (
+	customUnmarshalerType = reflect.TypeOf((*CustomUnmarshaler)(nil)).Elem()
+
+	// structPlans caches *structPlan by struct type
+	structPlans sync.Map
+	// mappingPlans caches *mappingPlan by unmarshal target type
+	mappingPlans sync.Map
+)
+
+// structPlan holds parsed tags and env names of struct fields.
+// Built once per type and shared by marshal, unmarshal and validation
+type structPlan struct {
+	fields []fieldPlan
+}
+
+type fieldPlan struct {
+	index    int
+	exported bool
+	tag      structtag.Tag
+	// name is tag's name or kebab-cased field name
+	name string
+}
+
+func structPlanOf(tp reflect.Type) *structPlan {
+	cached, ok := structPlans.Load(tp)
+	if ok {
+		return cached.(*structPlan)
+	}
+
+	p := &structPlan{
+		fields: make([]fieldPlan, 0, tp.NumField()),
+	}
+
+	for i := 0; i < tp.NumField(); i++ {
+		field := tp.Field(i)
+		ft := structtag.Parse(structtag.Lookup(field.Tag))
+		if ft.Skip {
+			continue
+		}
+
+		p.fields = append(p.fields, fieldPlan{
+			index:    i,
+			exported: field.IsExported(),
+			tag:      ft,
+			name:     ft.FieldName(field.Name),
+		})
+	}
+
+	cached, _ = structPlans.LoadOrStore(tp, p)
+	return cached.(*structPlan)
+}
+
+// valueConverter sets value of src node into target
+type valueConverter func(target reflect.Value, src *Node) error
+
+// mappingPlan is a compiled set of converters of unmarshal target
+// by upper-cased env name relative to unmarshal prefix.
+// e.g. for
+//
+//	struct{ Db struct{ Port int } }
+//
+// plan contains "DB_PORT" with field index [0 0] and int converter
+type mappingPlan struct {
+	entries  map[string]mappingEntry
+	required []string
+	// customStructs are names of struct fields with CustomUnmarshaler
+	// e.g. generated by evon-gen. They check their required fields themselves
+	customStructs []string
+	// recursive holds fields of recursive types.
+	// They are mapped with plan of their own type
+	recursive map[string]recursiveField
+}
+
+type mappingEntry struct {
+	index   []int
+	convert valueConverter
+}
+
+type recursiveField struct {
+	index []int
+	tp    reflect.Type
+}
+
+func mappingPlanOf(tp reflect.Type) *mappingPlan {
+	cached, ok := mappingPlans.Load(tp)
+	if ok {
+		return cached.(*mappingPlan)
+	}
+
+	p := &mappingPlan{
+		entries: make(map[string]mappingEntry),
+	}
+	p.compile("", tp, nil, map[reflect.Type]bool{})
+
+	cached, _ = mappingPlans.LoadOrStore(tp, p)
+	return cached.(*mappingPlan)
+}
+
+func (p *mappingPlan) compile(name string, tp reflect.Type, index []int, visiting map[reflect.Type]bool) {
+	for tp.Kind() == reflect.Pointer {
+		tp = tp.Elem()
+	}
+
+	var convert valueConverter
+
+	switch tp.Kind() {
+	case reflect.Struct:
+		if reflect.PointerTo(tp).Implements(customUnmarshalerType) {
+			convert = mapCustomUnmarshaler
+			p.customStructs = append(p.customStructs, name)
+			break
+		}
+
+		convert = getValueConverter(tp)
+		if convert != nil {
+			break
+		}
+
+		if visiting[tp] {
+			if p.recursive == nil {
+				p.recursive = make(map[string]recursiveField)
+			}
+			p.recursive[name] = recursiveField{index: index, tp: tp}
+			return
+		}
+		visiting[tp] = true
+		defer delete(visiting, tp)
+
+		prefix := name
+		if prefix != "" {
+			prefix += ObjectSplitter
+		}
+
+		for _, f := range structPlanOf(tp).fields {
+			fieldName := strings.ToUpper(prefix + f.name)
+			if f.tag.Required {
+				p.required = append(p.required, fieldName)
+			}
+
+			fieldIndex := append(index[:len(index):len(index)], f.index)
+			p.compile(fieldName, tp.Field(f.index).Type, fieldIndex, visiting)
+		}
+
+		return
+
+	case reflect.Slice, reflect.Map:
+		if reflect.PointerTo(tp).Implements(customUnmarshalerType) {
+			convert = mapCustomUnmarshaler
+		} else {
+			convert = mapSlice
+		}
+
+	default:
+		convert = getValueConverter(tp)
+	}
+
+	if convert != nil {
+		p.entries[name] = mappingEntry{
+			index:   index,
+			convert: convert,
+		}
+	}
+}
+
+// set maps src into field of root by name relative to unmarshal prefix.
+// Returns false if there is no such field or it's behind nil pointer
+func (p *mappingPlan) set(root reflect.Value, name string, src *Node) (bool, error) {
+	entry, ok := p.entries[name]
+	if !ok {
+		return p.setRecursive(root, name, src)
+	}
+
+	target, ok := resolveField(root, entry.index)
+	if !ok || !target.CanAddr() {
+		return false, nil
+	}
+
+	return true, entry.convert(target, src)
+}
+
+func (p *mappingPlan) setRecursive(root reflect.Value, name string, src *Node) (bool, error) {
+	for fieldName, field := range p.recursive {
+		rest, ok := relativeName(fieldName, name)
+		if !ok {
+			continue
+		}
+
+		target, ok := resolveField(root, field.index)
+		if !ok {
+			return false, nil
+		}
+
+		return mappingPlanOf(field.tp).set(target, rest, src)
+	}
+
+	return false, nil
+}
+
+// has returns true if name or any of its parents is mapped
+func (p *mappingPlan) has(name string) bool {
+	for fieldName, field := range p.recursive {
+		rest, ok := relativeName(fieldName, name)
+		if ok {
+			return mappingPlanOf(field.tp).has(rest)
+		}
+	}
+
+	for {
+		if _, ok := p.entries[name]; ok {
+			return true
+		}
+
+		if name == "" {
+			return false
+		}
+
+		idx := strings.LastIndex(name, ObjectSplitter)
+		if idx == -1 {
+			idx = 0
+		}
+		name = name[:idx]
+	}
+}
+
+func resolveField(root reflect.Value, index []int) (reflect.Value, bool) {
+	target, ok := derefValue(root)
+	if !ok {
+		return reflect.Value{}, false
+	}
+
+	for _, i := range index {
+		target, ok = derefValue(target.Field(i))
+		if !ok {
+			return reflect.Value{}, false
+		}
+	}
+
+	return target, true
+}
+
+func derefValue(v reflect.Value) (reflect.Value, bool) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return reflect.Value{}, false
+		}
+		v = v.Elem()
+	}
+
+	return v, v.IsValid()
+}
+
+// relativeName trims upper-cased prefix from env name.
+// Returns false if name is not under prefix
+func relativeName(prefix, name string) (string, bool) {
+	if prefix == "" {
+		return name, true
+	}
+
+	if !strings.HasPrefix(name, prefix) {
+		return "", false
+	}
+
+	rest := name[len(prefix):]
+	if rest == "" {
+		return "", true
+	}
+
+	if !strings.HasPrefix(rest, ObjectSplitter) {
+		return "", false
+	}
+
+	return rest[len(ObjectSplitter):], true
+}
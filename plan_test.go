@@ -0,0 +1,115 @@
+package evon
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type matreshkaTestConfig struct {
+	AppInfo     matreshkaTestAppInfo     `evon:"APP-INFO"`
+	DataSources matreshkaTestDataSources `evon:"DATA-SOURCES"`
+}
+
+type matreshkaTestAppInfo struct {
+	Name            string
+	StartupDuration time.Duration
+	Version         string
+}
+
+type matreshkaTestDataSources struct {
+	GrpcRscliExample struct {
+		ConnectionString string
+		Module           string
+	} `evon:"GRPC-RSCLI-EXAMPLE"`
+	Postgres struct {
+		DbName           string `evon:"DB-NAME,required"`
+		Host             string `evon:"HOST,nonempty"`
+		MigrationsFolder string
+		Port             uint16 `evon:"PORT,min=1"`
+		Pwd              string
+		SslMode          string `evon:"SSL-MODE,oneof=disable|require"`
+		User             string
+	}
+	Redis struct {
+		Db   int
+		Host string
+		Port uint16
+		Pwd  string
+		User string
+	}
+	TelegramBot struct {
+		ApiKey string
+	}
+}
+
+func Test_PlanCache(t *testing.T) {
+	t.Parallel()
+
+	ns := ParseToNodes(matreshkaDotEnv)
+
+	for range 2 {
+		var cfg matreshkaTestConfig
+		require.NoError(t, UnmarshalWithNodes(ns, &cfg))
+
+		require.Equal(t, "v0.0.1", cfg.AppInfo.Version)
+		require.Equal(t, 10*time.Second, cfg.AppInfo.StartupDuration)
+		require.Equal(t, uint16(5433), cfg.DataSources.Postgres.Port)
+		require.Equal(t, "0.0.0.0:50051", cfg.DataSources.GrpcRscliExample.ConnectionString)
+		require.Equal(t, "jjggwwkk", cfg.DataSources.TelegramBot.ApiKey)
+	}
+
+	cached, ok := mappingPlans.Load(reflect.TypeOf(&matreshkaTestConfig{}))
+	require.True(t, ok)
+
+	plan := cached.(*mappingPlan)
+	require.Equal(t, []string{"DATA-SOURCES_POSTGRES_DB-NAME"}, plan.required)
+	require.Equal(t, []int{1, 1, 3}, plan.entries["DATA-SOURCES_POSTGRES_PORT"].index)
+}
+
+func Test_PlanRecursiveType(t *testing.T) {
+	t.Parallel()
+
+	type tree struct {
+		Name  string
+		Child *tree
+	}
+
+	dst := tree{Child: &tree{}}
+	err := Unmarshal([]byte(`NAME=root
+CHILD_NAME=child`), &dst)
+	require.NoError(t, err)
+	require.Equal(t, "root", dst.Name)
+	require.Equal(t, "child", dst.Child.Name)
+}
+
+func BenchmarkUnmarshal_Matreshka(b *testing.B) {
+	ns := ParseToNodes(matreshkaDotEnv)
+
+	b.ReportAllocs()
+	for range b.N {
+		var cfg matreshkaTestConfig
+		err := UnmarshalWithNodes(ns, &cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshal_Matreshka(b *testing.B) {
+	var cfg matreshkaTestConfig
+	err := Unmarshal(matreshkaDotEnv, &cfg)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for range b.N {
+		_, err = MarshalEnv(&cfg)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package evon
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// FromProperties converts java .properties file into tree of nodes.
+// Dots in keys are replaced with ObjectSplitter, "key[i]" becomes "KEY_[i]" node.
+// Keys are upper-cased and "_" in them is replaced with FieldSplitter.
+// Keys and values are separated with "=", ":" or whitespace,
+// lines ending with "\" are continued on next line,
+// lines starting with "#" or "!" are comments
+// e.g.
+//
+//	data.sources.postgres.host = localhost
+//	servers[0].port: 8080
+//	greeting = hello \
+//	           world
+//
+// is converted into
+//
+//	DATA_SOURCES_POSTGRES_HOST=localhost
+//	SERVERS_[0]_PORT=8080
+//	GREETING=hello world
+func FromProperties(data []byte) (*Node, error) {
+	root := &Node{}
+
+	lines := splitPropertiesLines(string(data))
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+
+		for isContinuedLine(line) {
+			line = line[:len(line)-1]
+			if i+1 == len(lines) {
+				break
+			}
+
+			i++
+			line += strings.TrimLeft(lines[i], " \t\f")
+		}
+
+		rawKey, rawValue := splitPropertiesLine(line)
+
+		key, err := unescapeProperties(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing properties at line %d: %w", lineNumber, err)
+		}
+
+		value, err := unescapeProperties(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing properties at line %d: %w", lineNumber, err)
+		}
+
+		n := root
+		for _, part := range strings.Split(key, ".") {
+			n, err = resolveIndexedKey(n, part)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing properties at line %d: %w", lineNumber, err)
+			}
+		}
+
+		n.Value = value
+	}
+
+	return root, nil
+}
+
+// splitPropertiesLines splits by "\n", "\r\n" and "\r"
+func splitPropertiesLines(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+
+	return strings.Split(s, "\n")
+}
+
+// isContinuedLine checks if line ends with odd number of backslashes
+func isContinuedLine(line string) bool {
+	slashes := len(line) - len(strings.TrimRight(line, `\`))
+	return slashes%2 == 1
+}
+
+// splitPropertiesLine splits line by first unescaped separator.
+// Separator is "=" or ":" surrounded by optional whitespace or whitespace only
+func splitPropertiesLine(line string) (key, value string) {
+	i := 0
+	for i < len(line) {
+		c := line[i]
+		if c == '\\' {
+			i += 2
+			continue
+		}
+
+		if c == '=' || c == ':' || c == ' ' || c == '\t' || c == '\f' {
+			break
+		}
+
+		i++
+	}
+
+	i = min(i, len(line))
+	key, value = line[:i], line[i:]
+
+	value = strings.TrimLeft(value, " \t\f")
+	if value != "" && (value[0] == '=' || value[0] == ':') {
+		value = strings.TrimLeft(value[1:], " \t\f")
+	}
+
+	return key, value
+}
+
+func unescapeProperties(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	sb := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' {
+			sb.WriteByte(c)
+			continue
+		}
+
+		i++
+		if i == len(s) {
+			break
+		}
+
+		switch s[i] {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			r, err := parsePropertiesUnicode(s[i+1:])
+			if err != nil {
+				return "", err
+			}
+			i += 4
+
+			// surrogate pair is written as two escapes
+			// e.g. \ud83d\ude00
+			if utf16.IsSurrogate(r) && strings.HasPrefix(s[i+1:], `\u`) {
+				low, err := parsePropertiesUnicode(s[i+3:])
+				decoded := utf16.DecodeRune(r, low)
+				if err == nil && decoded != unicode.ReplacementChar {
+					r = decoded
+					i += 6
+				}
+			}
+
+			sb.WriteRune(r)
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+
+	return sb.String(), nil
+}
+
+func parsePropertiesUnicode(s string) (rune, error) {
+	if len(s) < 4 {
+		return 0, fmt.Errorf("malformed \\uxxxx encoding")
+	}
+
+	code, err := strconv.ParseUint(s[:4], 16, 16)
+	if err != nil {
+		return 0, fmt.Errorf("malformed \\uxxxx encoding \\u%s", s[:4])
+	}
+
+	return rune(code), nil
+}
+
+// ToProperties converts tree of nodes into java .properties file.
+// ObjectSplitter is replaced with dots, "_[i]" nodes become "key[i]" keys.
+// Names are lower-cased, FieldSplitter is kept
+// e.g. "DATA-SOURCES_POSTGRES_DB-NAME" -> "data-sources.postgres.db-name".
+// Separators, whitespace and non-ASCII characters are escaped.
+// Nodes without value are skipped
+func ToProperties(n *Node) ([]byte, error) {
+	b := &bytes.Buffer{}
+
+	err := writeProperties(b, n, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+func writeProperties(b *bytes.Buffer, n *Node, key string) error {
+	if n.Value != nil && key != "" {
+		b.WriteString(escapePropertiesKey(key))
+		b.WriteByte('=')
+		b.WriteString(escapePropertiesValue(valueToString(n.Value)))
+		b.WriteByte('\n')
+	}
+
+	if len(n.InnerNodes) == 0 {
+		return nil
+	}
+
+	inner, isSlice := sliceElements(n)
+	if !isSlice {
+		inner = n.InnerNodes
+	}
+
+	if isSlice && key == "" {
+		return fmt.Errorf("node %s is a slice and can't be converted to properties", n.Name)
+	}
+
+	for _, child := range inner {
+		name := relativeChildName(n.Name, child.Name)
+
+		var childKey string
+		switch {
+		case isSlice:
+			childKey = key + name
+		case name == "" || strings.ContainsAny(name, ".[]"):
+			return fmt.Errorf("name of node %s can't be converted to properties key", child.Name)
+		case key == "":
+			childKey = lowerName(name)
+		default:
+			childKey = key + "." + lowerName(name)
+		}
+
+		err := writeProperties(b, child, childKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func escapePropertiesKey(s string) string {
+	return escapeProperties(s, true)
+}
+
+func escapePropertiesValue(s string) string {
+	return escapeProperties(s, false)
+}
+
+func escapeProperties(s string, isKey bool) string {
+	sb := &strings.Builder{}
+
+	for i, r := range s {
+		if r == utf8.RuneError {
+			// invalid utf-8 is kept as is
+			if _, size := utf8.DecodeRuneInString(s[i:]); size == 1 {
+				sb.WriteByte(s[i])
+				continue
+			}
+		}
+
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case ' ':
+			// leading whitespace of value is skipped while parsing
+			if isKey || i == 0 {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+		case '=', ':', '#', '!':
+			if isKey {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+		default:
+			if r < 0x20 || r > 0x7e {
+				for _, u := range utf16.Encode([]rune{r}) {
+					fmt.Fprintf(sb, `\u%04x`, u)
+				}
+
+				continue
+			}
+
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
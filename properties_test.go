@@ -0,0 +1,169 @@
+package evon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromProperties(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected string
+	}
+
+	tests := map[string]testCase{
+		"EMPTY": {
+			input:    "# comment\n! comment\n",
+			expected: "",
+		},
+		"SEPARATORS": {
+			input: `
+data-sources.postgres.host = localhost
+data-sources.postgres.port:5432
+data-sources.postgres.db_name   matreshka
+data-sources.postgres.ssl-mode
+  app.url = jdbc:postgresql://localhost:5432/db
+`,
+			expected: `DATA-SOURCES_POSTGRES_HOST=localhost
+DATA-SOURCES_POSTGRES_PORT=5432
+DATA-SOURCES_POSTGRES_DB-NAME=matreshka
+DATA-SOURCES_POSTGRES_SSL-MODE=
+APP_URL=jdbc:postgresql://localhost:5432/db
+`,
+		},
+		"CONTINUATION": {
+			input: "greeting = hello \\\n    world\r\nfruits = apple, \\\n         banana\\\\\nlast = end\\",
+			expected: `GREETING=hello world
+FRUITS=apple, banana\
+LAST=end
+`,
+		},
+		"ESCAPES": {
+			input: `key\ with\:separators = \ value\twithé 😀 #not comment
+`,
+			expected: "KEY WITH:SEPARATORS= value\twithé 😀 #not comment\n",
+		},
+		"LISTS": {
+			input: `
+servers[0].port = 8080
+servers[1].port = 8081
+hosts[] = a
+hosts[] = b
+`,
+			expected: `SERVERS_[0]_PORT=8080
+SERVERS_[1]_PORT=8081
+HOSTS_[0]=a
+HOSTS_[1]=b
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root, err := FromProperties([]byte(tc.input))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(Marshal(root.InnerNodes)))
+		})
+	}
+}
+
+func Test_FromPropertiesError(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input string
+		err   string
+	}
+
+	tests := map[string]testCase{
+		"MALFORMED_UNICODE": {
+			input: "a=1\nb=\\u00zz",
+			err:   `error parsing properties at line 2: malformed \uxxxx encoding \u00zz`,
+		},
+		"SHORT_UNICODE": {
+			input: "b=\\u00",
+			err:   `error parsing properties at line 1: malformed \uxxxx encoding`,
+		},
+		"EMPTY_KEY": {
+			input: "a..b=1",
+			err:   `error parsing properties at line 1: empty key ""`,
+		},
+		"INVALID_INDEX": {
+			input: "a[b]=1",
+			err:   `error parsing properties at line 1: invalid index in key "a[b]"`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := FromProperties([]byte(tc.input))
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func Test_ToProperties(t *testing.T) {
+	t.Parallel()
+
+	ns := ParseToNodes([]byte(`
+DATA-SOURCES_POSTGRES_HOST=localhost
+DATA-SOURCES_POSTGRES_URL=jdbc:postgresql://localhost:5432/db
+APP=matreshka
+APP_GREETING=  hello # é
+SERVERS_[1]_PORT=8081
+SERVERS_[0]_PORT=8080
+HOSTS_[0]=a
+`))
+
+	actual, err := ToProperties(ns[""])
+	require.NoError(t, err)
+	require.Equal(t, `data-sources.postgres.host=localhost
+data-sources.postgres.url=jdbc:postgresql://localhost:5432/db
+app=matreshka
+app.greeting=\  hello # \u00e9
+servers[0].port=8080
+servers[1].port=8081
+hosts[0]=a
+`, string(actual))
+
+	root := &Node{InnerNodes: []*Node{{Name: "KEY = 😀", Value: "v"}}}
+	actual, err = ToProperties(root)
+	require.NoError(t, err)
+	require.Equal(t, "key\\ \\=\\ \\ud83d\\ude00=v\n", string(actual))
+
+	back, err := FromProperties(actual)
+	require.NoError(t, err)
+	require.Equal(t, NodeDiff{}, Diff(root, back))
+
+	ns = ParseToNodes([]byte("A.B=1\n"))
+	_, err = ToProperties(ns[""])
+	require.ErrorContains(t, err, "name of node A.B can't be converted to properties key")
+}
+
+func Test_PropertiesStruct(t *testing.T) {
+	t.Parallel()
+
+	var expected matreshkaTestConfig
+	require.NoError(t, Unmarshal(matreshkaDotEnv, &expected))
+
+	root, err := MarshalEnv(expected)
+	require.NoError(t, err)
+
+	data, err := ToProperties(root)
+	require.NoError(t, err)
+
+	back, err := FromProperties(data)
+	require.NoError(t, err)
+
+	var actual matreshkaTestConfig
+	err = UnmarshalWithNodes(NodesToStorage(back), &actual)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual, string(data))
+}
@@ -0,0 +1,277 @@
+package evon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+const (
+	envFileExt = ".env"
+)
+
+var (
+	ErrMissingSource = errors.New("mandatory source is missing")
+)
+
+// Source provides nodes from configuration provider e.g. file, process env, vault etc.
+// Implement fmt.Stringer to give source readable name in LayeredStorage.Origins
+type Source interface {
+	Load(ctx context.Context) (NodeStorage, error)
+}
+
+// SourceFunc allows to use plain function as Source
+type SourceFunc func(ctx context.Context) (NodeStorage, error)
+
+func (s SourceFunc) Load(ctx context.Context) (NodeStorage, error) {
+	return s(ctx)
+}
+
+// UniteSources loads sources one by one and unites them.
+// Every next source overrides previous ones
+func UniteSources(ctx context.Context, sources []Source, opts ...UniteOpt) (*LayeredStorage, error) {
+	layers := make([]Layer, 0, len(sources))
+
+	for _, s := range sources {
+		err := ctx.Err()
+		if err != nil {
+			return nil, err
+		}
+
+		ns, err := s.Load(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error loading %s: %w", sourceName(s), err)
+		}
+
+		if ns == nil {
+			continue
+		}
+
+		layers = append(layers, Layer{
+			Name:    sourceName(s),
+			Storage: ns,
+		})
+	}
+
+	return UniteLayers(layers, opts...)
+}
+
+func sourceName(s Source) string {
+	st, ok := s.(fmt.Stringer)
+	if ok {
+		return st.String()
+	}
+
+	return fmt.Sprintf("%T", s)
+}
+
+// Optional turns source into optional one:
+// if source returns ErrMissingSource it's skipped
+func Optional(s Source) Source {
+	return &optionalSource{Source: s}
+}
+
+type optionalSource struct {
+	Source
+}
+
+func (o *optionalSource) Load(ctx context.Context) (NodeStorage, error) {
+	ns, err := o.Source.Load(ctx)
+	if err != nil {
+		if errors.Is(err, ErrMissingSource) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return ns, nil
+}
+
+func (o *optionalSource) String() string {
+	return sourceName(o.Source)
+}
+
+// FileSource reads variables from .env file.
+// Returns ErrMissingSource if file doesn't exist
+func FileSource(path string) Source {
+	return &fileSource{path: path}
+}
+
+type fileSource struct {
+	path string
+}
+
+func (f *fileSource) Load(_ context.Context) (NodeStorage, error) {
+	b, err := os.ReadFile(f.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: file %s doesn't exist", ErrMissingSource, f.path)
+		}
+
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	return ParseToNodes(b), nil
+}
+
+func (f *fileSource) String() string {
+	return f.path
+}
+
+// BytesSource parses variables from .env formatted bytes
+func BytesSource(b []byte) Source {
+	return bytesSource(b)
+}
+
+type bytesSource []byte
+
+func (b bytesSource) Load(_ context.Context) (NodeStorage, error) {
+	return ParseToNodes(b), nil
+}
+
+func (b bytesSource) String() string {
+	return "bytes"
+}
+
+// ProcessEnvSource takes variables of current process.
+// Only variables with prefix are taken if prefix is not empty
+func ProcessEnvSource(prefix string) Source {
+	return &processEnvSource{prefix: strings.ToUpper(prefix)}
+}
+
+type processEnvSource struct {
+	prefix string
+}
+
+func (p *processEnvSource) Load(_ context.Context) (NodeStorage, error) {
+	ns := NodeStorage{}
+
+	for _, kv := range os.Environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || name == "" {
+			continue
+		}
+
+		if p.prefix != "" && !strings.HasPrefix(name, p.prefix+ObjectSplitter) {
+			continue
+		}
+
+		ns.AddNode(&Node{
+			Name:  name,
+			Value: value,
+		})
+	}
+
+	return ns, nil
+}
+
+func (p *processEnvSource) String() string {
+	return processEnvLayer
+}
+
+// MapSource takes variables from map
+func MapSource(m map[string]string) Source {
+	return mapSource(m)
+}
+
+type mapSource map[string]string
+
+func (m mapSource) Load(_ context.Context) (NodeStorage, error) {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ns := NodeStorage{}
+	for _, name := range names {
+		ns.AddNode(&Node{
+			Name:  name,
+			Value: m[name],
+		})
+	}
+
+	return ns, nil
+}
+
+func (m mapSource) String() string {
+	return "map"
+}
+
+// FSSource reads variables from .env file inside fs.FS e.g. embed.FS.
+// Returns ErrMissingSource if file doesn't exist
+func FSSource(fsys fs.FS, name string) Source {
+	return &fsSource{fsys: fsys, name: name}
+}
+
+type fsSource struct {
+	fsys fs.FS
+	name string
+}
+
+func (f *fsSource) Load(_ context.Context) (NodeStorage, error) {
+	b, err := fs.ReadFile(f.fsys, f.name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: file %s doesn't exist", ErrMissingSource, f.name)
+		}
+
+		return nil, fmt.Errorf("error reading file: %w", err)
+	}
+
+	return ParseToNodes(b), nil
+}
+
+func (f *fsSource) String() string {
+	return f.name
+}
+
+// DirSource reads every *.env file in directory in lexical order
+// e.g. 00-defaults.env, 10-db.env, 20-servers.env.
+// Returns ErrMissingSource if directory doesn't exist
+func DirSource(dir string) Source {
+	return &dirSource{dir: dir}
+}
+
+type dirSource struct {
+	dir string
+}
+
+func (d *dirSource) Load(ctx context.Context) (NodeStorage, error) {
+	fsys := os.DirFS(d.dir)
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: directory %s doesn't exist", ErrMissingSource, d.dir)
+		}
+
+		return nil, fmt.Errorf("error reading directory: %w", err)
+	}
+
+	sources := make([]Source, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != envFileExt {
+			continue
+		}
+
+		sources = append(sources, FSSource(fsys, e.Name()))
+	}
+
+	ls, err := UniteSources(ctx, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	return ls.Storage, nil
+}
+
+func (d *dirSource) String() string {
+	return d.dir
+}
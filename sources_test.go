@@ -0,0 +1,115 @@
+package evon
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSources(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "10-db.env"), `DB_HOST=localhost
+DB_PORT=5432`)
+	writeTestFile(t, filepath.Join(dir, "20-db.env"), `DB_PORT=6432`)
+	writeTestFile(t, filepath.Join(dir, "README.md"), `NOT_ENV=1`)
+
+	fsys := fstest.MapFS{
+		"config/.env": {Data: []byte(`DB_HOST=fs`)},
+	}
+
+	type testCase struct {
+		source   Source
+		expected string
+	}
+
+	tests := map[string]testCase{
+		"bytes": {
+			source:   BytesSource([]byte(`DB_HOST=bytes`)),
+			expected: "DB_HOST=bytes\n",
+		},
+		"empty_bytes": {
+			source:   BytesSource(nil),
+			expected: "",
+		},
+		"map": {
+			source: MapSource(map[string]string{
+				"DB_PORT": "5432",
+				"DB_HOST": "map",
+			}),
+			expected: "DB_HOST=map\nDB_PORT=5432\n",
+		},
+		"fs": {
+			source:   FSSource(fsys, "config/.env"),
+			expected: "DB_HOST=fs\n",
+		},
+		"dir": {
+			source:   DirSource(dir),
+			expected: "DB_HOST=localhost\nDB_PORT=6432\n",
+		},
+		"func": {
+			source: SourceFunc(func(ctx context.Context) (NodeStorage, error) {
+				return ParseToNodes([]byte(`DB_HOST=func`)), nil
+			}),
+			expected: "DB_HOST=func\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			ns, err := tc.source.Load(ctx)
+			require.NoError(t, err)
+
+			var actual []byte
+			if root := ns[""]; root != nil {
+				actual = Marshal(root.InnerNodes)
+			}
+			require.Equal(t, tc.expected, string(actual))
+		})
+	}
+}
+
+func TestSourcesMissing(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	for _, s := range []Source{
+		FileSource(filepath.Join(dir, ".env")),
+		FSSource(fstest.MapFS{}, ".env"),
+		DirSource(filepath.Join(dir, "secrets")),
+	} {
+		_, err := s.Load(ctx)
+		require.ErrorIs(t, err, ErrMissingSource)
+
+		ns, err := Optional(s).Load(ctx)
+		require.NoError(t, err)
+		require.Nil(t, ns)
+	}
+}
+
+func TestProcessEnvSource(t *testing.T) {
+	t.Setenv("EVON-TEST_HOST", "env")
+	t.Setenv("OTHER_HOST", "other")
+
+	ls, err := UniteSources(context.Background(), []Source{
+		MapSource(map[string]string{"EVON-TEST_HOST": "map", "EVON-TEST_PORT": "80"}),
+		ProcessEnvSource("evon-test"),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "EVON-TEST_HOST=env\nEVON-TEST_PORT=80\n", string(Marshal(ls.Storage[""].InnerNodes)))
+	require.Equal(t, map[string]string{
+		"EVON-TEST_HOST": processEnvLayer,
+		"EVON-TEST_PORT": "map",
+	}, ls.Origins)
+}
@@ -0,0 +1,794 @@
+package evon
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FromTOML converts toml document into tree of nodes.
+// Tables, dotted keys and inline tables become nested nodes,
+// arrays and arrays of tables become "_[i]" nodes.
+// Keys are upper-cased and "_" in them is replaced with FieldSplitter.
+// Strings are unescaped, other values are kept as written
+// except for "_" separators and hex, octal and binary integers
+// that are converted to decimal
+// e.g.
+//
+//	[server]
+//	http_port = 8080
+//	hosts = ["a", "b"]
+//
+// is converted into
+//
+//	SERVER_HTTP-PORT=8080
+//	SERVER_HOSTS_[0]=a
+//	SERVER_HOSTS_[1]=b
+func FromTOML(data []byte) (*Node, error) {
+	root := &Node{}
+
+	p := &tomlParser{
+		src:         string(data),
+		table:       root,
+		root:        root,
+		arrayTables: map[*Node]bool{},
+	}
+
+	err := p.parse()
+	if err != nil {
+		return nil, fmt.Errorf("error parsing toml at line %d: %w", p.lineNumber(), err)
+	}
+
+	return root, nil
+}
+
+type tomlParser struct {
+	src string
+	pos int
+
+	root *Node
+	// table is set by last [table] or [[table]] header
+	table *Node
+	// arrayTables holds nodes defined with [[table]] headers
+	arrayTables map[*Node]bool
+}
+
+func (p *tomlParser) parse() error {
+	for {
+		p.skipBlank(true)
+		if p.eof() {
+			return nil
+		}
+
+		var err error
+		switch {
+		case strings.HasPrefix(p.rest(), "[["):
+			err = p.parseArrayTableHeader()
+		case p.peek() == '[':
+			err = p.parseTableHeader()
+		default:
+			err = p.parseKeyValue(p.table)
+		}
+		if err != nil {
+			return err
+		}
+
+		p.skipBlank(false)
+		if !p.eof() && p.peek() != '\n' && p.peek() != '\r' {
+			return fmt.Errorf("expected new line, got %q", p.peek())
+		}
+	}
+}
+
+func (p *tomlParser) parseTableHeader() error {
+	p.pos++
+
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+
+	err = p.expect("]")
+	if err != nil {
+		return err
+	}
+
+	p.table = p.resolve(p.root, keys)
+	return nil
+}
+
+func (p *tomlParser) parseArrayTableHeader() error {
+	p.pos += 2
+
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+
+	err = p.expect("]]")
+	if err != nil {
+		return err
+	}
+
+	array := p.resolve(p.root, keys)
+	p.arrayTables[array] = true
+
+	p.table = appendElement(array)
+	return nil
+}
+
+func (p *tomlParser) parseKeyValue(table *Node) error {
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+
+	err = p.expect("=")
+	if err != nil {
+		return err
+	}
+
+	p.skipBlank(false)
+	return p.parseValue(p.resolve(table, keys))
+}
+
+// resolve returns node by keys relative to table creating missing ones.
+// Arrays of tables are resolved into their last element
+func (p *tomlParser) resolve(table *Node, keys []string) *Node {
+	n := table
+	for _, key := range keys {
+		if p.arrayTables[n] && len(n.InnerNodes) > 0 {
+			n = n.InnerNodes[len(n.InnerNodes)-1]
+		}
+
+		name := joinPath(n.Name, strings.ToUpper(nameToEvonName(key)))
+
+		inner := findInnerNode(n, name)
+		if inner == nil {
+			inner = &Node{Name: name}
+			n.InnerNodes = append(n.InnerNodes, inner)
+		}
+
+		n = inner
+	}
+
+	return n
+}
+
+// parseKey parses bare, quoted and dotted keys
+// e.g. `server."http.port"` -> ["server", "http.port"]
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+
+	for {
+		p.skipBlank(false)
+
+		var key string
+		switch p.peek() {
+		case '"':
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case '\'':
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			start := p.pos
+			for !p.eof() && isTOMLBareKeyChar(p.peek()) {
+				p.pos++
+			}
+
+			if start == p.pos {
+				return nil, fmt.Errorf("expected key, got %q", p.peek())
+			}
+			key = p.src[start:p.pos]
+		}
+
+		keys = append(keys, key)
+
+		p.skipBlank(false)
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseValue(n *Node) error {
+	switch {
+	case strings.HasPrefix(p.rest(), `"""`):
+		s, err := p.parseMultilineString(`"""`)
+		if err != nil {
+			return err
+		}
+		n.Value = s
+
+	case strings.HasPrefix(p.rest(), `'''`):
+		s, err := p.parseMultilineString(`'''`)
+		if err != nil {
+			return err
+		}
+		n.Value = s
+
+	case p.peek() == '"':
+		s, err := p.parseBasicString()
+		if err != nil {
+			return err
+		}
+		n.Value = s
+
+	case p.peek() == '\'':
+		s, err := p.parseLiteralString()
+		if err != nil {
+			return err
+		}
+		n.Value = s
+
+	case p.peek() == '[':
+		return p.parseArray(n)
+
+	case p.peek() == '{':
+		return p.parseInlineTable(n)
+
+	default:
+		v, err := p.parseScalar()
+		if err != nil {
+			return err
+		}
+		n.Value = v
+	}
+
+	return nil
+}
+
+func (p *tomlParser) parseArray(n *Node) error {
+	p.pos++
+
+	for {
+		p.skipBlank(true)
+		if p.peek() == ']' {
+			p.pos++
+			return nil
+		}
+
+		err := p.parseValue(appendElement(n))
+		if err != nil {
+			return err
+		}
+
+		p.skipBlank(true)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+			p.pos++
+			return nil
+		default:
+			return fmt.Errorf("expected ',' or ']' in array, got %q", p.peek())
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable(n *Node) error {
+	p.pos++
+
+	for {
+		p.skipBlank(false)
+		if p.peek() == '}' {
+			p.pos++
+			return nil
+		}
+
+		err := p.parseKeyValue(n)
+		if err != nil {
+			return err
+		}
+
+		p.skipBlank(false)
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return nil
+		default:
+			return fmt.Errorf("expected ',' or '}' in inline table, got %q", p.peek())
+		}
+	}
+}
+
+// parseScalar parses bools, numbers and dates
+func (p *tomlParser) parseScalar() (string, error) {
+	start := p.pos
+	for !p.eof() && isTOMLScalarChar(p.peek()) {
+		p.pos++
+	}
+
+	// date and time can be separated with space
+	// e.g. 1979-05-27 07:32:00Z
+	if p.pos-start == len("1979-05-27") && p.src[start+4] == '-' &&
+		len(p.rest()) > 3 && p.src[p.pos] == ' ' && isDigit(p.src[p.pos+1]) && isDigit(p.src[p.pos+2]) && p.src[p.pos+3] == ':' {
+		p.pos++
+		for !p.eof() && isTOMLScalarChar(p.peek()) {
+			p.pos++
+		}
+	}
+
+	v := p.src[start:p.pos]
+	if v == "" {
+		if p.eof() {
+			return "", fmt.Errorf("expected value, got end of file")
+		}
+		return "", fmt.Errorf("expected value, got %q", p.peek())
+	}
+
+	unsigned := strings.TrimLeft(v, "+-")
+	if len(unsigned) > 2 && unsigned[0] == '0' && strings.ContainsRune("xob", rune(unsigned[1])) {
+		i, err := strconv.ParseInt(v, 0, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid integer %s: %w", v, err)
+		}
+
+		return strconv.FormatInt(i, 10), nil
+	}
+
+	isDate := len(unsigned) > 4 && unsigned[4] == '-' || strings.Contains(unsigned, ":")
+	if unsigned != "" && isDigit(unsigned[0]) && !isDate {
+		v = strings.ReplaceAll(v, "_", "")
+	}
+
+	return v, nil
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++
+
+	sb := &strings.Builder{}
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", fmt.Errorf("unterminated string")
+		}
+
+		c := p.src[p.pos]
+		switch c {
+		case '"':
+			p.pos++
+			return sb.String(), nil
+		case '\\':
+			err := p.parseEscape(sb)
+			if err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+			p.pos++
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++
+
+	end := strings.IndexAny(p.rest(), "'\n")
+	if end == -1 || p.src[p.pos+end] != '\'' {
+		return "", fmt.Errorf("unterminated string")
+	}
+
+	s := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+func (p *tomlParser) parseMultilineString(delim string) (string, error) {
+	p.pos += len(delim)
+
+	// new line right after opening delimiter is trimmed
+	if strings.HasPrefix(p.rest(), "\r\n") {
+		p.pos += 2
+	} else if strings.HasPrefix(p.rest(), "\n") {
+		p.pos++
+	}
+
+	sb := &strings.Builder{}
+	for {
+		if p.eof() {
+			return "", fmt.Errorf("unterminated multi-line string")
+		}
+
+		if strings.HasPrefix(p.rest(), delim) {
+			p.pos += len(delim)
+
+			// up to two quotes are allowed right before closing delimiter
+			for i := 0; i < 2 && strings.HasPrefix(p.rest(), delim[:1]); i++ {
+				sb.WriteByte(delim[0])
+				p.pos++
+			}
+
+			return sb.String(), nil
+		}
+
+		c := p.src[p.pos]
+		if c != '\\' || delim == `'''` {
+			sb.WriteByte(c)
+			p.pos++
+			continue
+		}
+
+		// line ending backslash trims all whitespace up to next non-whitespace character
+		rest := strings.TrimLeft(p.rest()[1:], " \t")
+		if strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+			p.pos = len(p.src) - len(strings.TrimLeft(rest, " \t\r\n"))
+			continue
+		}
+
+		err := p.parseEscape(sb)
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+func (p *tomlParser) parseEscape(sb *strings.Builder) error {
+	p.pos++
+	if p.eof() {
+		return fmt.Errorf("unterminated escape sequence")
+	}
+
+	c := p.src[p.pos]
+	p.pos++
+
+	switch c {
+	case 'b':
+		sb.WriteByte('\b')
+	case 't':
+		sb.WriteByte('\t')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'r':
+		sb.WriteByte('\r')
+	case 'e':
+		sb.WriteByte(0x1b)
+	case '"', '\\':
+		sb.WriteByte(c)
+	case 'u', 'U':
+		size := 4
+		if c == 'U' {
+			size = 8
+		}
+
+		if len(p.rest()) < size {
+			return fmt.Errorf("invalid unicode escape")
+		}
+
+		code, err := strconv.ParseUint(p.src[p.pos:p.pos+size], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return fmt.Errorf("invalid unicode escape \\%c%s", c, p.src[p.pos:p.pos+size])
+		}
+
+		sb.WriteRune(rune(code))
+		p.pos += size
+	default:
+		return fmt.Errorf("invalid escape sequence \\%c", c)
+	}
+
+	return nil
+}
+
+// skipBlank skips spaces, tabs and comments.
+// New lines are skipped too if newLines is true
+func (p *tomlParser) skipBlank(newLines bool) {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t':
+			p.pos++
+		case '\r', '\n':
+			if !newLines {
+				return
+			}
+			p.pos++
+		case '#':
+			end := strings.IndexByte(p.rest(), '\n')
+			if end == -1 {
+				p.pos = len(p.src)
+				return
+			}
+			p.pos += end
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) expect(s string) error {
+	p.skipBlank(false)
+	if !strings.HasPrefix(p.rest(), s) {
+		if p.eof() {
+			return fmt.Errorf("expected %q, got end of file", s)
+		}
+		return fmt.Errorf("expected %q, got %q", s, p.peek())
+	}
+
+	p.pos += len(s)
+	return nil
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) rest() string {
+	return p.src[p.pos:]
+}
+
+func (p *tomlParser) lineNumber() int {
+	return strings.Count(p.src[:min(p.pos, len(p.src))], "\n") + 1
+}
+
+// appendElement adds new "_[i]" node to n
+func appendElement(n *Node) *Node {
+	elem := &Node{
+		Name: joinPath(n.Name, "["+strconv.Itoa(len(n.InnerNodes))+"]"),
+	}
+	n.InnerNodes = append(n.InnerNodes, elem)
+
+	return elem
+}
+
+func isTOMLBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c == '_' || c == '-'
+}
+
+func isTOMLScalarChar(c byte) bool {
+	return isTOMLBareKeyChar(c) || c == '+' || c == '.' || c == ':'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// ToTOML converts tree of nodes into toml document.
+// Names are lower-cased and FieldSplitter in them is replaced with "_".
+// Nodes with inner nodes become tables, "_[i]" nodes become arrays
+// or arrays of tables if every element is a table.
+// Values that look like numbers and bools are written unquoted.
+// Nodes without value are skipped
+func ToTOML(n *Node) ([]byte, error) {
+	b := &bytes.Buffer{}
+
+	err := writeTOMLTable(b, n, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// writeTOMLTable writes table with header.
+// Header of table is skipped if table holds only other tables
+func writeTOMLTable(b *bytes.Buffer, n *Node, path, header string) error {
+	if n.Value != nil {
+		return fmt.Errorf("node %s has both value and inner nodes and can't be converted to toml", n.Name)
+	}
+
+	type table struct {
+		node    *Node
+		path    string
+		isArray bool
+	}
+
+	var (
+		lines  []string
+		tables []table
+	)
+
+	for _, child := range n.InnerNodes {
+		key := tomlKey(fieldNameToKey(relativeChildName(n.Name, child.Name)))
+		childPath := key
+		if path != "" {
+			childPath = path + "." + key
+		}
+
+		if len(child.InnerNodes) == 0 {
+			if child.Value != nil {
+				lines = append(lines, key+" = "+tomlValue(child.Value))
+			}
+
+			continue
+		}
+
+		elems, isArray := sliceElements(child)
+		switch {
+		case !isArray:
+			tables = append(tables, table{node: child, path: childPath})
+		case isTOMLTableArray(elems):
+			tables = append(tables, table{node: child, path: childPath, isArray: true})
+		default:
+			v, err := tomlInlineValue(child)
+			if err != nil {
+				return err
+			}
+
+			lines = append(lines, key+" = "+v)
+		}
+	}
+
+	isArrayElement := strings.HasPrefix(header, "[[")
+	if header != "" && (len(lines) > 0 || isArrayElement) {
+		writeTOMLHeader(b, header)
+	}
+
+	for _, line := range lines {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+
+	for _, t := range tables {
+		if !t.isArray {
+			err := writeTOMLTable(b, t.node, t.path, "["+t.path+"]")
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		elems, _ := sliceElements(t.node)
+		for _, elem := range elems {
+			err := writeTOMLTable(b, elem, t.path, "[["+t.path+"]]")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func writeTOMLHeader(b *bytes.Buffer, header string) {
+	if b.Len() > 0 {
+		b.WriteByte('\n')
+	}
+
+	b.WriteString(header)
+	b.WriteByte('\n')
+}
+
+// isTOMLTableArray returns true if every element is a table
+func isTOMLTableArray(elems []*Node) bool {
+	for _, e := range elems {
+		if len(e.InnerNodes) == 0 {
+			return false
+		}
+
+		if _, isArray := sliceElements(e); isArray {
+			return false
+		}
+	}
+
+	return true
+}
+
+func tomlInlineValue(n *Node) (string, error) {
+	if len(n.InnerNodes) == 0 {
+		if n.Value == nil {
+			return "{}", nil
+		}
+
+		return tomlValue(n.Value), nil
+	}
+
+	if n.Value != nil {
+		return "", fmt.Errorf("node %s has both value and inner nodes and can't be converted to toml", n.Name)
+	}
+
+	elems, isArray := sliceElements(n)
+	if isArray {
+		parts := make([]string, 0, len(elems))
+		for _, e := range elems {
+			v, err := tomlInlineValue(e)
+			if err != nil {
+				return "", err
+			}
+
+			parts = append(parts, v)
+		}
+
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	}
+
+	parts := make([]string, 0, len(n.InnerNodes))
+	for _, child := range n.InnerNodes {
+		if len(child.InnerNodes) == 0 && child.Value == nil {
+			continue
+		}
+
+		v, err := tomlInlineValue(child)
+		if err != nil {
+			return "", err
+		}
+
+		parts = append(parts, tomlKey(fieldNameToKey(relativeChildName(n.Name, child.Name)))+" = "+v)
+	}
+
+	return "{ " + strings.Join(parts, ", ") + " }", nil
+}
+
+func tomlKey(key string) string {
+	for i := 0; i < len(key); i++ {
+		if !isTOMLBareKeyChar(key[i]) {
+			return tomlQuote(key)
+		}
+	}
+
+	if key == "" {
+		return `""`
+	}
+
+	return key
+}
+
+func tomlValue(v any) string {
+	s := valueToString(v)
+	if s == "true" || s == "false" || isJSONNumber(s) {
+		return s
+	}
+
+	return tomlQuote(s)
+}
+
+// tomlQuote returns s as toml basic string
+func tomlQuote(s string) string {
+	sb := &strings.Builder{}
+	sb.WriteByte('"')
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			// invalid utf-8 is kept as is
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+		i += size
+
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(sb, `\u%04X`, r)
+				continue
+			}
+
+			sb.WriteRune(r)
+		}
+	}
+
+	sb.WriteByte('"')
+	return sb.String()
+}
@@ -0,0 +1,191 @@
+package evon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromTOML(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected string
+	}
+
+	tests := map[string]testCase{
+		"EMPTY": {
+			input:    "# only comment\n",
+			expected: "",
+		},
+		"TABLES": {
+			input: `
+title = "loki" # comment
+[server]
+http_listen_port = 3_100
+grpc.port = 0x2387
+
+[storage_config.s3]
+endpoint = 'minio:9000'
+"bucket.names" = "loki"
+`,
+			expected: `TITLE=loki
+SERVER_HTTP-LISTEN-PORT=3100
+SERVER_GRPC_PORT=9095
+STORAGE-CONFIG_S3_ENDPOINT=minio:9000
+STORAGE-CONFIG_S3_BUCKET.NAMES=loki
+`,
+		},
+		"ARRAYS": {
+			input: `
+peers = [
+  "a", # first
+  "b",
+]
+matrix = [[1, 2], [3]]
+limits = { max = 10, rate.burst = 5 }
+`,
+			expected: `PEERS_[0]=a
+PEERS_[1]=b
+MATRIX_[0]_[0]=1
+MATRIX_[0]_[1]=2
+MATRIX_[1]_[0]=3
+LIMITS_MAX=10
+LIMITS_RATE_BURST=5
+`,
+		},
+		"ARRAY_OF_TABLES": {
+			input: `
+[[schema_config.configs]]
+from = 2020-10-24
+store = "boltdb-shipper"
+
+[schema_config.configs.index]
+period = "24h"
+
+[[schema_config.configs]]
+from = 1979-05-27 07:32:00Z
+`,
+			expected: `SCHEMA-CONFIG_CONFIGS_[0]_FROM=2020-10-24
+SCHEMA-CONFIG_CONFIGS_[0]_STORE=boltdb-shipper
+SCHEMA-CONFIG_CONFIGS_[0]_INDEX_PERIOD=24h
+SCHEMA-CONFIG_CONFIGS_[1]_FROM=1979-05-27 07:32:00Z
+`,
+		},
+		"STRINGS": {
+			input: `
+basic = "tab\there \"quoted\" \u00e9"
+literal = 'C:\path'
+multi = """
+first \
+  second"""
+multi_literal = '''
+raw \n'''
+`,
+			expected: `BASIC=tab	here "quoted" é
+LITERAL=C:\path
+MULTI=first second
+MULTI-LITERAL=raw \n
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root, err := FromTOML([]byte(tc.input))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(Marshal(root.InnerNodes)))
+		})
+	}
+}
+
+func Test_FromTOMLError(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input string
+		err   string
+	}
+
+	tests := map[string]testCase{
+		"NO_VALUE": {
+			input: "a = 1\nb =\n",
+			err:   `error parsing toml at line 2: expected value, got '\n'`,
+		},
+		"NO_VALUE_AT_EOF": {
+			input: "a =",
+			err:   "error parsing toml at line 1: expected value, got end of file",
+		},
+		"UNTERMINATED_STRING": {
+			input: `a = "b`,
+			err:   "error parsing toml at line 1: unterminated string",
+		},
+		"TWO_VALUES": {
+			input: "a = 1 2",
+			err:   `error parsing toml at line 1: expected new line, got '2'`,
+		},
+		"INVALID_ESCAPE": {
+			input: `a = "\q"`,
+			err:   `error parsing toml at line 1: invalid escape sequence \q`,
+		},
+		"UNCLOSED_HEADER": {
+			input: "[a\nb = 1",
+			err:   `error parsing toml at line 1: expected "]", got '\n'`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := FromTOML([]byte(tc.input))
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func Test_ToTOML(t *testing.T) {
+	t.Parallel()
+
+	ns := ParseToNodes([]byte(`
+AUTH-ENABLED=false
+SERVER_HTTP-LISTEN-PORT=3100
+SERVER_NAME=loki "main"
+SERVER_/{GRPC}_PORT=9095
+PEERS_[1]=b
+PEERS_[0]=a
+MATRIX_[0]_[0]=1
+MATRIX_[1]_A=2
+CONFIGS_[0]_FROM=2020-10-24
+CONFIGS_[0]_INDEX_PERIOD=24h
+CONFIGS_[1]_FROM=2021-01-01
+ZIP=007
+`))
+
+	actual, err := ToTOML(ns[""])
+	require.NoError(t, err)
+	require.Equal(t, `auth_enabled = false
+peers = ["a", "b"]
+matrix = [[1], { a = 2 }]
+zip = "007"
+
+[server]
+http_listen_port = 3100
+name = "loki \"main\""
+
+[server."/{grpc}"]
+port = 9095
+
+[[configs]]
+from = "2020-10-24"
+
+[configs.index]
+period = "24h"
+
+[[configs]]
+from = "2021-01-01"
+`, string(actual))
+}
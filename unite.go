@@ -1,5 +1,17 @@
 package evon
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUniteConflict = errors.New("unite conflict")
+)
+
 // Unite adds missing variables from src to trg
 // If value exist in trg changes it onto the one from src
 func Unite(src, trg *Node) {
@@ -15,3 +27,375 @@ func UniteStorages(src, trg NodeStorage) {
 		}
 	}
 }
+
+type ConflictStrategy int
+
+const (
+	// SrcWins - value from src overrides value in trg
+	SrcWins ConflictStrategy = iota
+	// TrgWins - value in trg is kept
+	TrgWins
+	// ErrorOnConflict - *UniteConflictError is returned and trg is left untouched
+	ErrorOnConflict
+)
+
+type SliceStrategy int
+
+const (
+	// SliceReplace - elements of slice in trg are replaced with elements from src.
+	// Different values under the same index are resolved as conflicts
+	SliceReplace SliceStrategy = iota
+	// SliceAppend - elements from src are appended after elements in trg
+	SliceAppend
+)
+
+// ConflictResolver returns value to be stored
+// when node exists both in trg and src with different values
+type ConflictResolver func(path string, trgValue, srcValue any) (any, error)
+
+type uniteOpts struct {
+	conflictStrategy ConflictStrategy
+	resolver         ConflictResolver
+	sliceStrategy    SliceStrategy
+}
+
+type UniteOpt func(o *uniteOpts)
+
+func WithConflictStrategy(s ConflictStrategy) UniteOpt {
+	return func(o *uniteOpts) {
+		o.conflictStrategy = s
+	}
+}
+
+// WithConflictResolver sets custom resolver. Overrides conflict strategy
+func WithConflictResolver(r ConflictResolver) UniteOpt {
+	return func(o *uniteOpts) {
+		o.resolver = r
+	}
+}
+
+func WithSliceStrategy(s SliceStrategy) UniteOpt {
+	return func(o *uniteOpts) {
+		o.sliceStrategy = s
+	}
+}
+
+type UniteConflictError struct {
+	// Conflicts holds value from trg as Old and value from src as New
+	Conflicts []NodeChange
+}
+
+func (e *UniteConflictError) Error() string {
+	paths := make([]string, 0, len(e.Conflicts))
+	for _, c := range e.Conflicts {
+		paths = append(paths, c.Path)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrUniteConflict, strings.Join(paths, ", "))
+}
+
+func (e *UniteConflictError) Unwrap() error {
+	return ErrUniteConflict
+}
+
+// Layer is a named source of nodes e.g. "defaults", ".env", ".env.local", "process env"
+type Layer struct {
+	Name    string
+	Storage NodeStorage
+}
+
+// LayeredStorage is a result of uniting layers
+type LayeredStorage struct {
+	Storage NodeStorage
+	// Origins holds name of layer each value came from
+	Origins map[string]string
+}
+
+// Origin returns name of layer the value of node came from
+func (l *LayeredStorage) Origin(path string) (string, bool) {
+	layer, ok := l.Origins[path]
+	return layer, ok
+}
+
+// UniteStoragesWith adds variables from src to trg
+// resolving conflicts according to passed options.
+// By default src wins and slices are replaced
+func UniteStoragesWith(src, trg NodeStorage, opts ...UniteOpt) error {
+	return uniteStorages(src, trg, "", map[string]string{}, newUniteOpts(opts))
+}
+
+// UniteLayers unites layers one by one from first to the last one.
+// Every next layer is treated as src for the result of previous ones
+func UniteLayers(layers []Layer, opts ...UniteOpt) (*LayeredStorage, error) {
+	o := newUniteOpts(opts)
+
+	out := &LayeredStorage{
+		Storage: NodeStorage{"": &Node{}},
+		Origins: map[string]string{},
+	}
+
+	for _, l := range layers {
+		err := uniteStorages(l.Storage, out.Storage, l.Name, out.Origins, o)
+		if err != nil {
+			return nil, fmt.Errorf("error uniting layer %s: %w", l.Name, err)
+		}
+	}
+
+	return out, nil
+}
+
+func newUniteOpts(opts []UniteOpt) uniteOpts {
+	o := uniteOpts{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
+
+func uniteStorages(src, trg NodeStorage, layer string, origins map[string]string, o uniteOpts) error {
+	sliceRoots := findSliceRoots(src)
+
+	leaves := make([]*Node, 0, len(src))
+	for path, n := range src {
+		if n.Value == nil || isUnderRoots(path, sliceRoots) {
+			continue
+		}
+
+		leaves = append(leaves, n)
+	}
+	sortNodes(leaves)
+
+	if o.resolver == nil && o.conflictStrategy == ErrorOnConflict {
+		candidates := leaves
+		if o.sliceStrategy == SliceReplace {
+			// elements replace ones of trg under the same indexes
+			for _, root := range sliceRoots {
+				candidates = append(candidates, sliceLeaves(src[root], 0)...)
+			}
+		}
+
+		var conflicts []NodeChange
+		for _, n := range candidates {
+			existing := trg[n.Name]
+			if existing == nil || existing.Value == nil || valuesEqual(existing.Value, n.Value) {
+				continue
+			}
+
+			conflicts = append(conflicts, NodeChange{
+				Path: n.Name,
+				Old:  existing.Value,
+				New:  n.Value,
+			})
+		}
+
+		if len(conflicts) != 0 {
+			return &UniteConflictError{Conflicts: conflicts}
+		}
+	}
+
+	for _, root := range sliceRoots {
+		err := uniteSlice(src[root], trg, layer, origins, o)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, n := range leaves {
+		existing := trg[n.Name]
+		if existing == nil || existing.Value == nil {
+			trg.AddNode(&Node{
+				Name:  n.Name,
+				Value: n.Value,
+			})
+			origins[n.Name] = layer
+			continue
+		}
+
+		if o.resolver != nil {
+			if valuesEqual(existing.Value, n.Value) {
+				continue
+			}
+
+			v, err := o.resolve(n.Name, existing.Value, n.Value)
+			if err != nil {
+				return err
+			}
+
+			if !valuesEqual(existing.Value, v) {
+				existing.Value = v
+				origins[n.Name] = layer
+			}
+			continue
+		}
+
+		if o.conflictStrategy != TrgWins {
+			existing.Value = n.Value
+			origins[n.Name] = layer
+		}
+	}
+
+	return nil
+}
+
+// uniteSlice puts elements of srcRoot into trg according to slice strategy.
+// Elements replacing ones of trg under the same indexes are resolved
+// as conflicts of values e.g. SERVERS_[0]_PORT=80 in trg and SERVERS_[0]_PORT=8080 in src
+func uniteSlice(srcRoot *Node, trg NodeStorage, layer string, origins map[string]string, o uniteOpts) error {
+	offset := 0
+	// replaced holds values of removed elements of trg and their origins
+	replaced := map[string]any{}
+	replacedOrigins := map[string]string{}
+
+	trgRoot := trg[srcRoot.Name]
+	if trgRoot != nil {
+		var elems []*Node
+		for _, n := range trgRoot.InnerNodes {
+			if isSliceElementName(n.Name) {
+				elems = append(elems, n)
+			}
+		}
+
+		switch o.sliceStrategy {
+		case SliceAppend:
+			offset = len(elems)
+		default:
+			for _, n := range elems {
+				for path, origin := range origins {
+					if path == n.Name || strings.HasPrefix(path, n.Name+ObjectSplitter) {
+						replacedOrigins[path] = origin
+						delete(origins, path)
+					}
+				}
+
+				walkValuedNodes([]*Node{n}, func(inner *Node) {
+					replaced[inner.Name] = inner.Value
+				})
+
+				trg.RemoveNode(n.Name)
+			}
+		}
+	}
+
+	for _, n := range sliceLeaves(srcRoot, offset) {
+		value, origin := n.Value, layer
+
+		old, ok := replaced[n.Name]
+		if ok && !valuesEqual(old, n.Value) {
+			v, err := o.resolve(n.Name, old, n.Value)
+			if err != nil {
+				return err
+			}
+
+			value = v
+			if valuesEqual(old, v) {
+				origin = replacedOrigins[n.Name]
+			}
+		}
+
+		trg.AddNode(&Node{
+			Name:  n.Name,
+			Value: value,
+		})
+		origins[n.Name] = origin
+	}
+
+	return nil
+}
+
+// resolve returns value to be stored for node with different values in trg and src.
+// ErrorOnConflict is checked before anything is changed, so src wins here
+func (o uniteOpts) resolve(path string, trgValue, srcValue any) (any, error) {
+	if o.resolver != nil {
+		v, err := o.resolver(path, trgValue, srcValue)
+		if err != nil {
+			return nil, fmt.Errorf("error resolving conflict for %s: %w", path, err)
+		}
+
+		return v, nil
+	}
+
+	if o.conflictStrategy == TrgWins {
+		return trgValue, nil
+	}
+
+	return srcValue, nil
+}
+
+// sliceLeaves returns nodes with values of elements of srcRoot
+// with indexes starting from offset
+func sliceLeaves(srcRoot *Node, offset int) []*Node {
+	var out []*Node
+	for idx, elem := range srcRoot.InnerNodes {
+		elemName := srcRoot.Name + ObjectSplitter + "[" + strconv.Itoa(idx+offset) + "]"
+
+		var addLeaves func(n *Node)
+		addLeaves = func(n *Node) {
+			if n.Value != nil {
+				out = append(out, &Node{
+					Name:  elemName + n.Name[len(elem.Name):],
+					Value: n.Value,
+				})
+			}
+
+			for _, inner := range n.InnerNodes {
+				addLeaves(inner)
+			}
+		}
+
+		addLeaves(elem)
+	}
+
+	return out
+}
+
+// findSliceRoots returns sorted names of top level nodes
+// which inner nodes are slice elements
+func findSliceRoots(s NodeStorage) []string {
+	var roots []string
+	for path, n := range s {
+		if path == "" || len(n.InnerNodes) == 0 {
+			continue
+		}
+
+		isSlice := true
+		for _, inner := range n.InnerNodes {
+			if !isSliceElementName(inner.Name) {
+				isSlice = false
+				break
+			}
+		}
+
+		if isSlice {
+			roots = append(roots, path)
+		}
+	}
+
+	sort.Strings(roots)
+
+	out := roots[:0]
+	for _, r := range roots {
+		if !isUnderRoots(r, out) {
+			out = append(out, r)
+		}
+	}
+
+	return out
+}
+
+func isUnderRoots(path string, roots []string) bool {
+	for _, r := range roots {
+		if path == r || strings.HasPrefix(path, r+ObjectSplitter) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSliceElementName(name string) bool {
+	idx := strings.LastIndex(name, ObjectSplitter)
+	last := name[idx+1:]
+
+	return strings.HasPrefix(last, "[") && strings.HasSuffix(last, "]")
+}
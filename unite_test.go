@@ -1,6 +1,7 @@
 package evon
 
 import (
+	"fmt"
 	"sort"
 	"testing"
 
@@ -125,3 +126,205 @@ func nodes() (*Node, *Node) {
 			},
 		}
 }
+
+func Test_UniteStoragesWith(t *testing.T) {
+	t.Parallel()
+
+	newStorages := func() (src, trg NodeStorage) {
+		return ParseToNodes([]byte(`DB_HOST=src
+DB_PORT=5432
+`)), ParseToNodes([]byte(`DB_HOST=trg
+DB_NAME=app
+`))
+	}
+
+	t.Run("src_wins", func(t *testing.T) {
+		t.Parallel()
+
+		src, trg := newStorages()
+		require.NoError(t, UniteStoragesWith(src, trg))
+		require.Equal(t, `DB_HOST=src
+DB_NAME=app
+DB_PORT=5432
+`, string(Marshal(trg[""].InnerNodes)))
+	})
+
+	t.Run("trg_wins", func(t *testing.T) {
+		t.Parallel()
+
+		src, trg := newStorages()
+		require.NoError(t, UniteStoragesWith(src, trg, WithConflictStrategy(TrgWins)))
+		require.Equal(t, `DB_HOST=trg
+DB_NAME=app
+DB_PORT=5432
+`, string(Marshal(trg[""].InnerNodes)))
+	})
+
+	t.Run("error_on_conflict", func(t *testing.T) {
+		t.Parallel()
+
+		src, trg := newStorages()
+		err := UniteStoragesWith(src, trg, WithConflictStrategy(ErrorOnConflict))
+
+		var conflictErr *UniteConflictError
+		require.ErrorAs(t, err, &conflictErr)
+		require.ErrorIs(t, err, ErrUniteConflict)
+		require.Equal(t, []NodeChange{{Path: "DB_HOST", Old: "trg", New: "src"}}, conflictErr.Conflicts)
+
+		require.Equal(t, `DB_HOST=trg
+DB_NAME=app
+`, string(Marshal(trg[""].InnerNodes)))
+	})
+
+	t.Run("resolver", func(t *testing.T) {
+		t.Parallel()
+
+		src, trg := newStorages()
+		err := UniteStoragesWith(src, trg,
+			WithConflictResolver(func(path string, trgValue, srcValue any) (any, error) {
+				return fmt.Sprint(trgValue, "-", srcValue), nil
+			}))
+		require.NoError(t, err)
+		require.Equal(t, `DB_HOST=trg-src
+DB_NAME=app
+DB_PORT=5432
+`, string(Marshal(trg[""].InnerNodes)))
+	})
+}
+
+func Test_UniteSlices(t *testing.T) {
+	t.Parallel()
+
+	newStorages := func() (src, trg NodeStorage) {
+		return ParseToNodes([]byte(`SERVERS_[0]_PORT=8080
+`)), ParseToNodes([]byte(`SERVERS_[0]_PORT=80
+SERVERS_[1]_PORT=443
+`))
+	}
+
+	t.Run("replace", func(t *testing.T) {
+		t.Parallel()
+
+		src, trg := newStorages()
+		require.NoError(t, UniteStoragesWith(src, trg))
+		require.Equal(t, `SERVERS_[0]_PORT=8080
+`, string(Marshal(trg[""].InnerNodes)))
+	})
+
+	t.Run("append", func(t *testing.T) {
+		t.Parallel()
+
+		src, trg := newStorages()
+		require.NoError(t, UniteStoragesWith(src, trg, WithSliceStrategy(SliceAppend)))
+		require.Equal(t, `SERVERS_[0]_PORT=80
+SERVERS_[1]_PORT=443
+SERVERS_[2]_PORT=8080
+`, string(Marshal(trg[""].InnerNodes)))
+	})
+
+	t.Run("replace_trg_wins", func(t *testing.T) {
+		t.Parallel()
+
+		src, trg := newStorages()
+		require.NoError(t, UniteStoragesWith(src, trg, WithConflictStrategy(TrgWins)))
+		require.Equal(t, `SERVERS_[0]_PORT=80
+`, string(Marshal(trg[""].InnerNodes)))
+	})
+
+	t.Run("replace_error_on_conflict", func(t *testing.T) {
+		t.Parallel()
+
+		src, trg := newStorages()
+		err := UniteStoragesWith(src, trg, WithConflictStrategy(ErrorOnConflict))
+
+		var conflictErr *UniteConflictError
+		require.ErrorAs(t, err, &conflictErr)
+		require.Equal(t, []NodeChange{{Path: "SERVERS_[0]_PORT", Old: "80", New: "8080"}}, conflictErr.Conflicts)
+
+		require.Equal(t, `SERVERS_[0]_PORT=80
+SERVERS_[1]_PORT=443
+`, string(Marshal(trg[""].InnerNodes)))
+	})
+
+	t.Run("append_error_on_conflict", func(t *testing.T) {
+		t.Parallel()
+
+		src, trg := newStorages()
+		err := UniteStoragesWith(src, trg,
+			WithConflictStrategy(ErrorOnConflict),
+			WithSliceStrategy(SliceAppend))
+		require.NoError(t, err)
+		require.Len(t, trg["SERVERS"].InnerNodes, 3)
+	})
+
+	t.Run("replace_resolver", func(t *testing.T) {
+		t.Parallel()
+
+		var paths []string
+
+		src, trg := newStorages()
+		err := UniteStoragesWith(src, trg,
+			WithConflictResolver(func(path string, trgValue, srcValue any) (any, error) {
+				paths = append(paths, path)
+				return fmt.Sprint(trgValue, "-", srcValue), nil
+			}))
+		require.NoError(t, err)
+		require.Equal(t, []string{"SERVERS_[0]_PORT"}, paths)
+		require.Equal(t, `SERVERS_[0]_PORT=80-8080
+`, string(Marshal(trg[""].InnerNodes)))
+	})
+
+	t.Run("layers_trg_wins", func(t *testing.T) {
+		t.Parallel()
+
+		src, trg := newStorages()
+		actual, err := UniteLayers([]Layer{
+			{Name: "defaults", Storage: trg},
+			{Name: "env", Storage: src},
+		}, WithConflictStrategy(TrgWins))
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"SERVERS_[0]_PORT": "defaults"}, actual.Origins)
+	})
+}
+
+func Test_UniteLayers(t *testing.T) {
+	t.Parallel()
+
+	layers := []Layer{
+		{
+			Name: "defaults",
+			Storage: ParseToNodes([]byte(`DB_HOST=localhost
+DB_PORT=5432
+LOG_LEVEL=info
+`)),
+		},
+		{
+			Name: ".env",
+			Storage: ParseToNodes([]byte(`DB_HOST=db
+`)),
+		},
+		{
+			Name: ".env.local",
+			Storage: ParseToNodes([]byte(`LOG_LEVEL=debug
+DB_HOST=db
+`)),
+		},
+	}
+
+	actual, err := UniteLayers(layers)
+	require.NoError(t, err)
+
+	require.Equal(t, `DB_HOST=db
+DB_PORT=5432
+LOG_LEVEL=debug
+`, string(Marshal(actual.Storage[""].InnerNodes)))
+
+	require.Equal(t, map[string]string{
+		"DB_HOST":   ".env.local",
+		"DB_PORT":   "defaults",
+		"LOG_LEVEL": ".env.local",
+	}, actual.Origins)
+
+	_, err = UniteLayers(layers, WithConflictStrategy(ErrorOnConflict))
+	require.ErrorIs(t, err, ErrUniteConflict)
+}
@@ -3,27 +3,33 @@ package evon
 import (
 	"fmt"
 	"reflect"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
-
-	"go.redsock.ru/rerrors"
 )
 
+// CustomUnmarshaler sets value from node by itself.
+// It's used for slices, maps and structs e.g. generated by evon-gen.
+// Structs are unmarshalled from empty node if there are no variables of them,
+// so they can report missing required variables.
+// Struct's UnmarshalEnv is responsible for its validation:
+// its fields are not checked again by Unmarshal
 type CustomUnmarshaler interface {
 	UnmarshalEnv(env *Node) error
 }
 
 type NodeMappingFunc func(v *Node) error
 
-func Unmarshal(bytes []byte, dst any) error {
+func Unmarshal(bytes []byte, dst any, opts ...unmarshalOpt) error {
 	srcNodes := ParseToNodes(bytes)
-	return unmarshal("", srcNodes, dst)
+	return unmarshal("", srcNodes, dst, opts...)
 }
 
-func UnmarshalWithPrefix(prefix string, bytes []byte, dst any) error {
+func UnmarshalWithPrefix(prefix string, bytes []byte, dst any, opts ...unmarshalOpt) error {
 	srcNodes := ParseToNodes(bytes)
-	return unmarshal(prefix, srcNodes, dst)
+	return unmarshal(prefix, srcNodes, dst, opts...)
 }
 
 func NodeToStruct(prefix string, node *Node, dst any) error {
@@ -38,13 +44,15 @@ func UnmarshalWithNodes(srcNodes NodeStorage, dst any, opts ...unmarshalOpt) err
 	return unmarshal("", srcNodes, dst, opts...)
 }
 
-func UnmarshalWithNodesAndPrefix(prefix string, srcNodes NodeStorage, dst any) error {
-	return unmarshal(prefix, srcNodes, dst)
+func UnmarshalWithNodesAndPrefix(prefix string, srcNodes NodeStorage, dst any, opts ...unmarshalOpt) error {
+	return unmarshal(prefix, srcNodes, dst, opts...)
 }
 
 func unmarshal(prefix string, srcNodes NodeStorage, dst any, opts ...unmarshalOpt) (err error) {
 	dstRefVal := reflect.ValueOf(dst)
 
+	unOpts := newUnmarshalOpts(opts)
+
 	var dstValuesMapper unmarshalMapper
 
 	switch dstRefVal.Kind() {
@@ -54,140 +62,211 @@ func unmarshal(prefix string, srcNodes NodeStorage, dst any, opts ...unmarshalOp
 			return fmt.Errorf("error mapping to Golang's map: %w", err)
 		}
 	default:
-		dstValuesMapper, err = newStructValueMapper(prefix, dstRefVal)
+		dstValuesMapper, err = newStructValueMapper(prefix, dstRefVal, unOpts)
 		if err != nil {
-			return fmt.Errorf("%w:%s", err, "error getting struct value")
+			return fmt.Errorf("error getting struct value: %w", err)
 		}
 
 	}
 
-	unOpts := unmarshalOpts{
-		keyName: func(s string) string { return s },
-	}
+	if unOpts.fileSuffix != "" {
+		upperPrefix := strings.ToUpper(prefix)
+		fromFiles, err := resolveFileIndirection(srcNodes, unOpts, func(key string) bool {
+			_, ok := relativeName(upperPrefix, key)
+			return ok && dstValuesMapper.Has(keyPathOf(key, unOpts))
+		})
+		if err != nil {
+			return fmt.Errorf("error resolving file indirection: %w", err)
+		}
 
-	for _, opt := range opts {
-		opt(&unOpts)
+		if len(fromFiles) != 0 {
+			srcNodes = withFileNodes(srcNodes, fromFiles)
+		}
 	}
 
-	for key, srcVal := range srcNodes {
-		keyPath := strings.Split(key, ObjectSplitter)
-		for i := range keyPath {
-			keyPath[i] = unOpts.keyName(keyPath[i])
+	keys := make([]string, 0, len(srcNodes))
+	for key := range srcNodes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var errs MultiError
+	// invalid holds errors of custom unmarshalers that validate their values.
+	// They don't stop validation of other fields
+	var invalid ValidationErrors
+	for _, key := range keys {
+		err = dstValuesMapper.Map(keyPathOf(key, unOpts), srcNodes[key])
+		if ve, ok := err.(ValidationErrors); ok {
+			invalid = append(invalid, ve...)
+		} else if err != nil {
+			if !hasPath(err) {
+				err = fmt.Errorf("error setting value of %s: %w", key, err)
+			}
+			errs = append(errs, err)
 		}
+	}
 
-		err = dstValuesMapper.Map(keyPath, srcVal)
-		if err != nil {
-			return fmt.Errorf("%w:%s", err, "error setting value")
-		}
+	err = dstValuesMapper.PostMapping()
+	if err != nil {
+		errs = append(errs, splitValidationErrors(err, &invalid)...)
 	}
 
-	dstValuesMapper.PostMapping()
+	if len(errs) != 0 {
+		return errs.errorOrNil()
+	}
+
+	if dstRefVal.Kind() != reflect.Map && !unOpts.skipValidation {
+		validateValue(strings.ToUpper(prefix), dstRefVal, &invalid, true)
+	}
+
+	if len(invalid) != 0 {
+		return invalid
+	}
 
 	return nil
 }
 
+// splitValidationErrors appends validation errors of err to invalid
+// and returns the rest
+func splitValidationErrors(err error, invalid *ValidationErrors) []error {
+	switch e := err.(type) {
+	case ValidationErrors:
+		*invalid = append(*invalid, e...)
+		return nil
+	case MultiError:
+		var rest []error
+		for _, inner := range e {
+			rest = append(rest, splitValidationErrors(inner, invalid)...)
+		}
+		return rest
+	default:
+		return []error{err}
+	}
+}
+
+func keyPathOf(key string, o unmarshalOpts) []string {
+	keyPath := strings.Split(key, ObjectSplitter)
+	for i := range keyPath {
+		keyPath[i] = o.keyName(keyPath[i])
+	}
+
+	return keyPath
+}
+
 type unmarshalMapper interface {
 	Map(keyPath []string, dst *Node) error
-	PostMapping()
+	PostMapping() error
+	// Has reports if value by keyPath would be set into destination
+	Has(keyPath []string) bool
 }
 
 type structValueMapper struct {
-	constructorsByPath map[string]NodeMappingFunc
+	dst  reflect.Value
+	plan *mappingPlan
+
+	prefix              string
+	disallowUnknownKeys bool
+
+	presented map[string]struct{}
+	unknown   []string
+	// mappedCustom are names of custom structs that got their nodes
+	mappedCustom map[string]bool
 }
 
 func (s *structValueMapper) Map(keyPath []string, dst *Node) error {
-	cbp, exists := s.constructorsByPath[strings.Join(keyPath, ObjectSplitter)]
-	if exists {
-		return cbp(dst)
+	key := strings.Join(keyPath, ObjectSplitter)
+	if s.presented != nil && (dst.Value != nil || len(dst.InnerNodes) != 0) {
+		s.presented[key] = struct{}{}
 	}
 
-	return nil
-}
-func (s *structValueMapper) PostMapping() {
+	name, ok := relativeName(s.prefix, key)
+	if !ok {
+		return nil
+	}
 
-}
-func newStructValueMapper(prefix string, dst reflect.Value) (unmarshalMapper, error) {
-	valuesMapper := &structValueMapper{
-		constructorsByPath: make(map[string]NodeMappingFunc),
+	mapped, err := s.plan.set(s.dst, name, dst)
+	if mapped && slices.Contains(s.plan.customStructs, name) {
+		s.mappedCustom[name] = true
 	}
 
-	err := extractMappingForTarget(prefix, dst, valuesMapper.constructorsByPath)
-	if err != nil {
-		return nil, fmt.Errorf("%w:%s", err, "error extracting mapping for target")
+	if mapped || err != nil {
+		return err
 	}
 
-	return valuesMapper, nil
+	if s.disallowUnknownKeys && dst.Value != nil && !s.plan.has(name) {
+		s.unknown = append(s.unknown, key)
+	}
+
+	return nil
 }
 
-func extractMappingForTarget(prefix string, target reflect.Value, valueMapping map[string]NodeMappingFunc) error {
-	kind := target.Kind()
+func (s *structValueMapper) Has(keyPath []string) bool {
+	name, ok := relativeName(s.prefix, strings.Join(keyPath, ObjectSplitter))
+	return ok && s.plan.has(name)
+}
 
-	var valueMapFunc NodeMappingFunc
-	switch kind {
-	case reflect.Pointer, reflect.Struct:
-		if kind == reflect.Pointer {
-			target = target.Elem()
-			return extractMappingForTarget(prefix, target, valueMapping)
-		}
+func (s *structValueMapper) PostMapping() error {
+	var errs MultiError
 
-		if prefix != "" {
-			prefix += "_"
+	// custom structs without nodes are unmarshalled from empty node,
+	// so they report missing required fields and failed rules
+	for _, name := range s.plan.customStructs {
+		if s.mappedCustom[name] {
+			continue
 		}
 
-		for i := 0; i < target.NumField(); i++ {
-			targetField := target.Type().Field(i)
-			tags := strings.Split(targetField.Tag.Get(envTag), ",")
-			var tag string
-			for _, t := range tags {
-				if t == tagOmitempty {
-					continue
-				}
-				tag = t
-				break
-			}
-
-			if tag == "-" {
-				continue
-			}
-
-			if tag == "" {
-				tag = splitToKebab(targetField.Name)
-			}
+		entry := s.plan.entries[name]
+		target, ok := resolveField(s.dst, entry.index)
+		if !ok || !target.CanAddr() {
+			continue
+		}
 
-			field := target.Field(i)
-			err := extractMappingForTarget(prefix+tag, field, valueMapping)
-			if err != nil {
-				return fmt.Errorf("%w", err)
-			}
+		nodeName := s.prefix
+		if name != "" {
+			nodeName = joinPath(s.prefix, name)
 		}
-		return nil
 
-	case reflect.Slice, reflect.Map:
-		// TODO добавить проверку на базовый / не базовый типы
-		if !target.CanAddr() {
-			return nil
+		err := entry.convert(target, &Node{Name: nodeName})
+		if err != nil {
+			errs = append(errs, err)
 		}
-		k := target.Addr()
+	}
 
-		val := k.Interface()
-		cm, ok := val.(CustomUnmarshaler)
-		if !ok {
-			cm = &defaultSliceUnmarshaller{
-				ref: k,
-			}
+	for _, r := range s.plan.required {
+		r = joinPath(s.prefix, r)
+		if _, ok := s.presented[r]; !ok {
+			errs = append(errs, &MissingRequiredError{Path: r})
 		}
-		valueMapFunc = cm.UnmarshalEnv
+	}
 
-	default:
-		valueMapFunc = getBasicTypeMappingFunc(kind, target)
+	for _, key := range s.unknown {
+		errs = append(errs, &UnknownKeyError{Path: key})
+	}
+
+	return errs.errorOrNil()
+}
+
+func newStructValueMapper(prefix string, dst reflect.Value, opts unmarshalOpts) (unmarshalMapper, error) {
+	if !dst.IsValid() {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedType, dst)
 	}
 
-	if valueMapFunc != nil {
-		envName := strings.ToUpper(prefix)
-		valueMapping[envName] = valueMapFunc
+	m := &structValueMapper{
+		dst:                 dst,
+		plan:                mappingPlanOf(dst.Type()),
+		prefix:              strings.ToUpper(prefix),
+		disallowUnknownKeys: opts.disallowUnknownKeys,
 	}
 
-	return nil
+	if len(m.plan.required) != 0 {
+		m.presented = make(map[string]struct{})
+	}
+
+	if len(m.plan.customStructs) != 0 {
+		m.mappedCustom = make(map[string]bool)
+	}
+
+	return m, nil
 }
 
 type mapValueMapper struct {
@@ -226,49 +305,68 @@ func (m mapValueMapper) Map(keyPath []string, dst *Node) error {
 	return nil
 }
 
-func (m mapValueMapper) PostMapping() {
-
-	var fixSlice func(root map[string]any) []any
+// Has returns true because map takes every key
+func (m mapValueMapper) Has([]string) bool {
+	return true
+}
 
-	fixSlice = func(root map[string]any) []any {
-		sliced := make([]any, 0)
+func (m mapValueMapper) PostMapping() error {
+	fixSlices(m.m)
+	return nil
+}
 
-		for key, v := range root {
-			innerMap, ok := v.(map[string]any)
-			if ok {
-				sl := fixSlice(innerMap)
-				if len(sl) > 0 {
-					root[key] = sl
-				}
-			}
+// fixSlices replaces inner maps with keys "[0]".."[n-1]" with slices.
+// Maps with any other keys are left as is
+func fixSlices(root map[string]any) {
+	for key, v := range root {
+		innerMap, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
 
-			sliceIndexStart := strings.Index(key, "[")
-			if sliceIndexStart == -1 {
-				continue
-			}
+		fixSlices(innerMap)
 
-			sliceIndexEnd := strings.Index(key, "]")
-			if sliceIndexStart == -1 {
-				continue
-			}
+		sl := mapToSlice(innerMap)
+		if sl != nil {
+			root[key] = sl
+		}
+	}
+}
 
-			indexStr := key[sliceIndexStart+1 : sliceIndexEnd]
-			index, err := strconv.Atoi(indexStr)
-			if err != nil {
-				continue
-			}
+func mapToSlice(m map[string]any) []any {
+	if len(m) == 0 {
+		return nil
+	}
 
-			if len(sliced) == 0 {
-				sliced = make([]any, len(root))
-			}
+	sliced := make([]any, len(m))
+	filled := make([]bool, len(m))
 
-			sliced[index] = v
+	for key, v := range m {
+		index, ok := parseSliceIndex(key)
+		if !ok || index >= len(sliced) || filled[index] {
+			return nil
 		}
 
-		return sliced
+		sliced[index] = v
+		filled[index] = true
+	}
+
+	return sliced
+}
+
+// parseSliceIndex parses index of slice element name
+// e.g. "[1]" -> 1
+func parseSliceIndex(key string) (int, bool) {
+	if len(key) < 3 || key[0] != '[' || key[len(key)-1] != ']' {
+		return 0, false
 	}
 
-	_ = fixSlice(m.m)
+	index, err := strconv.Atoi(key[1 : len(key)-1])
+	if err != nil || index < 0 {
+		return 0, false
+	}
+
+	return index, true
 }
 
 func (m mapValueMapper) mapWithType(val any) any {
@@ -317,28 +415,6 @@ func newMapValueMapper(dst any) (unmarshalMapper, error) {
 	return m, nil
 }
 
-func getBasicTypeMappingFunc(kind reflect.Kind, target reflect.Value) NodeMappingFunc {
-	switch kind {
-	case reflect.String:
-		return mapString(target)
-	case reflect.Bool:
-		return mapBool(target)
-
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		tp := target.Type().Name()
-		if tp == "Duration" {
-			return mapDuration(target)
-		} else {
-			return mapInt(target)
-		}
-
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
-		return mapUint(target)
-	default:
-		return nil
-	}
-}
-
 type defaultSliceUnmarshaller struct {
 	ref reflect.Value
 }
@@ -347,22 +423,42 @@ func (d *defaultSliceUnmarshaller) UnmarshalEnv(rootSlice *Node) error {
 	typpedSlice := d.ref.Elem()
 	elemType := typpedSlice.Type().Elem()
 
+	if len(rootSlice.InnerNodes) == 0 && rootSlice.Value != nil {
+		return mapCommaSlice(typpedSlice, rootSlice)
+	}
+
+	// elements with custom unmarshalers validate themselves,
+	// so failed rules of every element are collected
+	var invalid ValidationErrors
 	for idx, e := range rootSlice.InnerNodes {
 		newElem := reflect.New(elemType).Elem()
 		ns := NodeStorage{}
 
-		e.RemovePrefix(e.Name)
-		ns.AddNode(e)
+		// element is unmarshalled as root, so its copy is renamed
+		// to keep nodes of caller's storage untouched
+		elem := e.Clone()
+		elem.RemovePrefix(e.Name)
+		ns.AddNode(elem)
 
 		ne := newElem.Addr().Interface()
-		err := unmarshal("", ns, ne)
+		err := unmarshal("", ns, ne, withoutValidation())
 		if err != nil {
-			return rerrors.Wrapf(err,
-				"error unmarshalling struct inside array. Path: %s_[%d]", rootSlice.Name, idx)
+			prependErrorPath(err, fmt.Sprintf("%s_[%d]", rootSlice.Name, idx))
+
+			ve, ok := err.(ValidationErrors)
+			if !ok {
+				return err
+			}
+			invalid = append(invalid, ve...)
 		}
 
 		typpedSlice.Set(reflect.Append(typpedSlice, newElem))
 	}
+
+	if len(invalid) != 0 {
+		return invalid
+	}
+
 	return nil
 }
 
@@ -1,6 +1,8 @@
 package evon
 
 import (
+	"errors"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -183,3 +185,135 @@ func TestUnmarshalMatreshkaToMap(t *testing.T) {
 	r, _ := yaml.Marshal(expected)
 	print(string(r))
 }
+
+func TestUnmarshalSlicesToMap(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    []byte
+		expected map[string]any
+	}
+
+	tests := map[string]testCase{
+		"slice_within_slice": {
+			input: []byte(`
+ARR_[0]_A_[0]=x
+ARR_[0]_A_[1]=y
+ARR_[1]_B=z
+`),
+			expected: map[string]any{
+				"ARR": []any{
+					map[string]any{"A": []any{"x", "y"}},
+					map[string]any{"B": "z"},
+				},
+			},
+		},
+		"index_out_of_range": {
+			input: []byte(`ARR_[5]=x`),
+			expected: map[string]any{
+				"ARR": map[string]any{"[5]": "x"},
+			},
+		},
+		"mixed_keys": {
+			input: []byte(`
+ARR_[0]=x
+ARR_NAME=y
+`),
+			expected: map[string]any{
+				"ARR": map[string]any{"[0]": "x", "NAME": "y"},
+			},
+		},
+		"malformed_index": {
+			input: []byte(`[0=0`),
+			expected: map[string]any{
+				"[0": 0,
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := map[string]any{}
+			err := Unmarshal(tc.input, actual)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestUnmarshalKeepsNodes(t *testing.T) {
+	t.Parallel()
+
+	type Server struct {
+		Port int `evon:"PORT"`
+	}
+
+	type Config struct {
+		Servers []Server `evon:"SERVERS"`
+	}
+
+	ns := ParseToNodes([]byte(`SERVERS_[0]_PORT=80
+SERVERS_[1]_PORT=443
+`))
+	expected := ns[""].Clone()
+
+	for range 2 {
+		var actual Config
+		require.NoError(t, UnmarshalWithNodes(ns, &actual))
+		require.Equal(t, Config{Servers: []Server{{Port: 80}, {Port: 443}}}, actual)
+		require.Equal(t, expected, ns[""])
+	}
+}
+
+// selfValidatedPort is unmarshalled and validated by itself
+type selfValidatedPort struct {
+	Port  int
+	calls int
+}
+
+func (p *selfValidatedPort) UnmarshalEnv(n *Node) error {
+	p.calls++
+
+	for _, inner := range n.InnerNodes {
+		if inner.Name == joinPath(n.Name, "PORT") {
+			p.Port, _ = strconv.Atoi(valueToString(inner.Value))
+		}
+	}
+
+	if p.Port == 0 {
+		return ValidationErrors{{Path: joinPath(n.Name, "PORT"), Err: errors.New("must be set")}}
+	}
+
+	return nil
+}
+
+func TestUnmarshalCustomStruct(t *testing.T) {
+	t.Parallel()
+
+	type Config struct {
+		Main    selfValidatedPort   `evon:"MAIN"`
+		Backups []selfValidatedPort `evon:"BACKUPS"`
+		Missing selfValidatedPort   `evon:"MISSING"`
+		Name    string              `evon:"NAME,nonempty"`
+	}
+
+	var actual Config
+	err := Unmarshal([]byte(`MAIN_PORT=80
+BACKUPS_[0]_PORT=0
+BACKUPS_[1]_PORT=0
+`), &actual)
+	require.ErrorIs(t, err, ErrValidation)
+	require.Equal(t, selfValidatedPort{Port: 80, calls: 1}, actual.Main)
+	require.Equal(t, 1, actual.Missing.calls)
+
+	var invalid ValidationErrors
+	require.ErrorAs(t, err, &invalid)
+
+	paths := make([]string, 0, len(invalid))
+	for _, e := range invalid {
+		paths = append(paths, e.Path)
+	}
+	require.ElementsMatch(t, []string{"BACKUPS_[0]_PORT", "BACKUPS_[1]_PORT", "MISSING_PORT", "NAME"}, paths)
+}
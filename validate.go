@@ -0,0 +1,379 @@
+package evon
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.redsock.ru/evon/internal/structtag"
+)
+
+const (
+	metadataTypeSuffix = "_TYPE"
+	metadataEnumSuffix = "_ENUM"
+)
+
+var (
+	ErrValidation = errors.New("validation failed")
+
+	durationType = reflect.TypeOf(time.Duration(0))
+	regexCache   sync.Map
+)
+
+// Validator is implemented by structs that check themselves after unmarshalling.
+// ValidateEnv is called bottom-up: nested structs are validated before their parents
+type Validator interface {
+	ValidateEnv() error
+}
+
+// ValidationError describes failed rule of a single variable
+type ValidationError struct {
+	Path string
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ValidationError) prependPath(prefix string) {
+	if e.Path == "" {
+		// error of Validator of root value
+		e.Path = prefix
+		return
+	}
+
+	e.Path = joinPath(prefix, e.Path)
+}
+
+// ValidationErrors aggregates every failed rule
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, ve := range e {
+		msgs = append(msgs, ve.Error())
+	}
+
+	return fmt.Sprintf("%s: %s", ErrValidation, strings.Join(msgs, "; "))
+}
+
+func (e ValidationErrors) Is(target error) bool {
+	return target == ErrValidation
+}
+
+func (e ValidationErrors) Unwrap() []error {
+	out := make([]error, 0, len(e))
+	for _, ve := range e {
+		out = append(out, ve)
+	}
+
+	return out
+}
+
+// Validate checks tag rules and calls Validator on every nested struct of v.
+// Prefix is used to build full variable names in errors
+func Validate(prefix string, v any) error {
+	var errs ValidationErrors
+	validateValue(strings.ToUpper(prefix), reflect.ValueOf(v), &errs, false)
+
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validateValue appends failed rules of v and its nested values to errs.
+// If skipCustom is set, structs with CustomUnmarshaler are skipped
+// because they are validated by their own UnmarshalEnv
+func validateValue(path string, v reflect.Value, errs *ValidationErrors, skipCustom bool) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+
+		validateValue(path, v.Elem(), errs, skipCustom)
+		return
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(joinPath(path, "["+strconv.Itoa(i)+"]"), v.Index(i), errs, skipCustom)
+		}
+		return
+
+	case reflect.Struct:
+		if v.Type() == timeType {
+			return
+		}
+
+		if skipCustom && reflect.PointerTo(v.Type()).Implements(customUnmarshalerType) {
+			return
+		}
+
+		for _, f := range structPlanOf(v.Type()).fields {
+			if !f.exported {
+				continue
+			}
+
+			fieldPath := joinPath(path, strings.ToUpper(f.name))
+
+			if f.tag.HasRules() {
+				err := checkRules(f.tag, v.Field(f.index))
+				if err != nil {
+					*errs = append(*errs, &ValidationError{Path: fieldPath, Err: err})
+				}
+			}
+
+			validateValue(fieldPath, v.Field(f.index), errs, skipCustom)
+		}
+
+		validator, ok := asValidator(v)
+		if ok {
+			err := validator.ValidateEnv()
+			if err != nil {
+				*errs = append(*errs, &ValidationError{Path: path, Err: err})
+			}
+		}
+	}
+}
+
+func asValidator(v reflect.Value) (Validator, bool) {
+	if v.CanAddr() {
+		validator, ok := v.Addr().Interface().(Validator)
+		if ok {
+			return validator, true
+		}
+	}
+
+	if v.CanInterface() {
+		validator, ok := v.Interface().(Validator)
+		return validator, ok
+	}
+
+	return nil, false
+}
+
+func checkRules(ft structtag.Tag, v reflect.Value) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			if ft.Nonempty {
+				return errors.New("must not be empty")
+			}
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if ft.Nonempty && v.IsZero() {
+		return errors.New("must not be empty")
+	}
+
+	if ft.Min != "" || ft.Max != "" {
+		err := checkRange(ft, v)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(ft.OneOf) != 0 {
+		str := extractString(v)
+		if !slices.Contains(ft.OneOf, str) {
+			return fmt.Errorf("%q must be one of %s", str, strings.Join(ft.OneOf, structtag.OneOfSeparator))
+		}
+	}
+
+	if ft.Regex != "" {
+		re, err := compileRegex(ft.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex rule %q: %w", ft.Regex, err)
+		}
+
+		str := extractString(v)
+		if !re.MatchString(str) {
+			return fmt.Errorf("%q must match %s", str, ft.Regex)
+		}
+	}
+
+	return nil
+}
+
+// checkRange compares numbers by value and strings, slices and maps by length
+func checkRange(ft structtag.Tag, v reflect.Value) error {
+	var actual float64
+	parse := func(s string) (float64, error) {
+		return strconv.ParseFloat(s, 64)
+	}
+
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		actual = float64(v.Int())
+		if v.Type() == durationType {
+			parse = func(s string) (float64, error) {
+				d, err := time.ParseDuration(s)
+				return float64(d), err
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		actual = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		actual = v.Float()
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		actual = float64(v.Len())
+	default:
+		return fmt.Errorf("min and max rules are not applicable to %s", v.Kind())
+	}
+
+	if ft.Min != "" {
+		minimum, err := parse(ft.Min)
+		if err != nil {
+			return fmt.Errorf("invalid min rule %q: %w", ft.Min, err)
+		}
+
+		if actual < minimum {
+			return fmt.Errorf("must be greater than or equal to %s", ft.Min)
+		}
+	}
+
+	if ft.Max != "" {
+		maximum, err := parse(ft.Max)
+		if err != nil {
+			return fmt.Errorf("invalid max rule %q: %w", ft.Max, err)
+		}
+
+		if actual > maximum {
+			return fmt.Errorf("must be less than or equal to %s", ft.Max)
+		}
+	}
+
+	return nil
+}
+
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	cached, ok := regexCache.Load(expr)
+	if ok {
+		return cached.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	regexCache.Store(expr, re)
+	return re, nil
+}
+
+// ValidateMetadata checks variables against matreshka-like metadata
+// e.g.
+//
+//	ENVIRONMENT_REQUEST-TIMEOUT_TYPE=duration
+//	ENVIRONMENT_ONE-OF-WELCOME-STRING_ENUM=[one,two,three]
+//
+// requires ENVIRONMENT_REQUEST-TIMEOUT to be a duration
+// and ENVIRONMENT_ONE-OF-WELCOME-STRING to be one of listed values.
+// Comma separated values are checked one by one.
+// Supported types: string, int, float, bool, duration
+func ValidateMetadata(ns NodeStorage) error {
+	var errs ValidationErrors
+
+	keys := make([]string, 0, len(ns))
+	for key := range ns {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		meta := ns[key]
+		if meta.Value == nil {
+			continue
+		}
+
+		var path string
+		var check func(s string) error
+
+		switch {
+		case strings.HasSuffix(key, metadataTypeSuffix):
+			path = strings.TrimSuffix(key, metadataTypeSuffix)
+			check = typeChecker(valueToString(meta.Value))
+		case strings.HasSuffix(key, metadataEnumSuffix):
+			path = strings.TrimSuffix(key, metadataEnumSuffix)
+			check = enumChecker(valueToString(meta.Value))
+		default:
+			continue
+		}
+
+		n, ok := ns[path]
+		if !ok || n.Value == nil {
+			continue
+		}
+
+		for _, v := range strings.Split(valueToString(n.Value), sliceSeparator) {
+			err := check(v)
+			if err != nil {
+				errs = append(errs, &ValidationError{Path: path, Err: err})
+				break
+			}
+		}
+	}
+
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func typeChecker(tp string) func(s string) error {
+	return func(s string) error {
+		var err error
+		switch tp {
+		case "int":
+			_, err = strconv.ParseInt(s, 10, 64)
+		case "float":
+			_, err = strconv.ParseFloat(s, 64)
+		case "bool":
+			_, err = strconv.ParseBool(s)
+		case "duration":
+			_, err = time.ParseDuration(s)
+		}
+
+		if err != nil {
+			return fmt.Errorf("%q is not %s", s, tp)
+		}
+
+		return nil
+	}
+}
+
+func enumChecker(enum string) func(s string) error {
+	enum = strings.TrimSuffix(strings.TrimPrefix(enum, "["), "]")
+	values := strings.Split(enum, sliceSeparator)
+
+	return func(s string) error {
+		if !slices.Contains(values, s) {
+			return fmt.Errorf("%q must be one of %s", s, strings.Join(values, structtag.OneOfSeparator))
+		}
+
+		return nil
+	}
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+
+	return prefix + ObjectSplitter + name
+}
@@ -0,0 +1,97 @@
+package evon
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errNoReplicas = errors.New("at least one replica is required")
+
+type validateTestPostgres struct {
+	Host     string        `evon:"HOST,nonempty"`
+	Port     uint16        `evon:"PORT,min=1,max=65535"`
+	SslMode  string        `evon:"SSL-MODE,oneof=disable|require|verify-full"`
+	User     string        `evon:"USER,regex=^[a-z_]{1,16}$"`
+	Timeout  time.Duration `evon:"TIMEOUT,omitempty,max=1m"`
+	Replicas []string      `evon:"REPLICAS"`
+}
+
+func (p *validateTestPostgres) ValidateEnv() error {
+	if len(p.Replicas) == 0 {
+		return errNoReplicas
+	}
+
+	return nil
+}
+
+type validateTestConfig struct {
+	Postgres validateTestPostgres `evon:"POSTGRES"`
+}
+
+func TestUnmarshalValidation(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg validateTestConfig
+		err := UnmarshalWithPrefix("APP", []byte(`APP_POSTGRES_HOST=localhost
+APP_POSTGRES_PORT=5432
+APP_POSTGRES_SSL-MODE=disable
+APP_POSTGRES_USER=matreshka
+APP_POSTGRES_TIMEOUT=10s
+APP_POSTGRES_REPLICAS=replica1,replica2`), &cfg)
+		require.NoError(t, err)
+	})
+
+	t.Run("ERRORS", func(t *testing.T) {
+		t.Parallel()
+
+		var cfg validateTestConfig
+		err := UnmarshalWithPrefix("APP", []byte(`APP_POSTGRES_PORT=0
+APP_POSTGRES_SSL-MODE=allow
+APP_POSTGRES_USER=Matreshka
+APP_POSTGRES_TIMEOUT=2m`), &cfg)
+		require.ErrorIs(t, err, ErrValidation)
+		require.ErrorIs(t, err, errNoReplicas)
+
+		var errs ValidationErrors
+		require.ErrorAs(t, err, &errs)
+
+		paths := make([]string, 0, len(errs))
+		for _, ve := range errs {
+			paths = append(paths, ve.Path)
+		}
+
+		require.Equal(t, []string{
+			"APP_POSTGRES_HOST",
+			"APP_POSTGRES_PORT",
+			"APP_POSTGRES_SSL-MODE",
+			"APP_POSTGRES_USER",
+			"APP_POSTGRES_TIMEOUT",
+			"APP_POSTGRES",
+		}, paths)
+	})
+}
+
+func TestValidateMetadata(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ValidateMetadata(ParseToNodes(matreshkaDotEnv)))
+
+	ns := ParseToNodes([]byte(`ENVIRONMENT_AVAILABLE-PORTS_TYPE=int
+ENVIRONMENT_AVAILABLE-PORTS=80,http
+ENVIRONMENT_REQUEST-TIMEOUT_TYPE=duration
+ENVIRONMENT_REQUEST-TIMEOUT=10s
+ENVIRONMENT_ONE-OF-WELCOME-STRING_ENUM=[one,two,three]
+ENVIRONMENT_ONE-OF-WELCOME-STRING=four`))
+
+	err := ValidateMetadata(ns)
+	require.ErrorIs(t, err, ErrValidation)
+	require.EqualError(t, err, `validation failed: `+
+		`ENVIRONMENT_AVAILABLE-PORTS: "http" is not int; `+
+		`ENVIRONMENT_ONE-OF-WELCOME-STRING: "four" must be one of one|two|three`)
+}
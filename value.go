@@ -0,0 +1,156 @@
+package evon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Value holds typed config snapshot that can be safely read
+// while being replaced e.g. by Watcher or Reload.
+// Every reload unmarshalls nodes into fresh instance of T
+// and publishes it only if unmarshalling and validation succeeded,
+// so readers never see half-populated struct
+type Value[T any] struct {
+	p    atomic.Pointer[T]
+	opts valueOpts[T]
+
+	// generation is incremented when update starts.
+	// Snapshot of update is published only if no later update was published,
+	// so slow reload of outdated nodes can't replace newer snapshot
+	generation atomic.Uint64
+	mu         sync.Mutex
+	published  uint64
+
+	changesOnce sync.Once
+	changes     chan *T
+}
+
+type valueOpts[T any] struct {
+	prefix        string
+	unmarshalOpts []unmarshalOpt
+	validate      func(v *T) error
+}
+
+// ValueOpt configures Value of the same type,
+// so e.g. validation of other type doesn't compile
+type ValueOpt[T any] func(o *valueOpts[T])
+
+// WithValuePrefix sets prefix used to unmarshal nodes in Reload
+func WithValuePrefix[T any](prefix string) ValueOpt[T] {
+	return func(o *valueOpts[T]) {
+		o.prefix = prefix
+	}
+}
+
+// WithValueUnmarshalOpts sets options used to unmarshal nodes
+func WithValueUnmarshalOpts[T any](opts ...unmarshalOpt) ValueOpt[T] {
+	return func(o *valueOpts[T]) {
+		o.unmarshalOpts = append(o.unmarshalOpts, opts...)
+	}
+}
+
+// WithValidate sets function that checks new snapshot before it's published
+func WithValidate[T any](f func(v *T) error) ValueOpt[T] {
+	return func(o *valueOpts[T]) {
+		o.validate = f
+	}
+}
+
+func NewValue[T any](opts ...ValueOpt[T]) *Value[T] {
+	v := &Value[T]{}
+	for _, opt := range opts {
+		opt(&v.opts)
+	}
+
+	return v
+}
+
+// Load returns current snapshot or nil if nothing was published yet.
+// Returned value must not be modified
+func (v *Value[T]) Load() *T {
+	return v.p.Load()
+}
+
+// Changes returns channel that receives every published snapshot.
+// Channel keeps only the latest snapshot if it's not read in time
+func (v *Value[T]) Changes() <-chan *T {
+	return v.changesChan()
+}
+
+// Reload loads and unites sources and publishes new snapshot on success.
+// On error current snapshot is kept
+func (v *Value[T]) Reload(ctx context.Context, sources ...Source) error {
+	gen := v.generation.Add(1)
+
+	ls, err := UniteSources(ctx, sources)
+	if err != nil {
+		return err
+	}
+
+	return v.update(gen, v.opts.prefix, ls.Storage, v.opts.unmarshalOpts)
+}
+
+// Set unmarshalls nodes and publishes new snapshot on success.
+// On error current snapshot is kept
+func (v *Value[T]) Set(ns NodeStorage) error {
+	return v.update(v.generation.Add(1), v.opts.prefix, ns, v.opts.unmarshalOpts)
+}
+
+// update unmarshalls nodes into new snapshot.
+// gen must be taken before nodes were read
+func (v *Value[T]) update(gen uint64, prefix string, ns NodeStorage, opts []unmarshalOpt) error {
+	t := new(T)
+
+	err := UnmarshalWithNodesAndPrefix(prefix, ns, t, opts...)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling nodes: %w", err)
+	}
+
+	if v.opts.validate != nil {
+		err = v.opts.validate(t)
+		if err != nil {
+			return fmt.Errorf("error validating new value: %w", err)
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if gen < v.published {
+		// newer snapshot is already published
+		return nil
+	}
+
+	v.published = gen
+	v.store(t)
+	return nil
+}
+
+func (v *Value[T]) store(t *T) {
+	v.p.Store(t)
+
+	changes := v.changesChan()
+	for {
+		select {
+		case changes <- t:
+			return
+		default:
+		}
+
+		// drop outdated snapshot nobody has read yet
+		select {
+		case <-changes:
+		default:
+		}
+	}
+}
+
+func (v *Value[T]) changesChan() chan *T {
+	v.changesOnce.Do(func() {
+		v.changes = make(chan *T, 1)
+	})
+
+	return v.changes
+}
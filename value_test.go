@@ -0,0 +1,137 @@
+package evon
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type valueTestConfig struct {
+	Port    int    `env:"PORT"`
+	PortStr string `env:"PORT-STR"`
+}
+
+func TestValueReload(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	errInvalidPort := errors.New("invalid port")
+	v := NewValue[valueTestConfig](
+		WithValuePrefix[valueTestConfig]("APP"),
+		WithValidate(func(c *valueTestConfig) error {
+			if c.Port == 0 {
+				return errInvalidPort
+			}
+			return nil
+		}),
+	)
+	require.Nil(t, v.Load())
+
+	err := v.Reload(ctx, MapSource(map[string]string{"APP_PORT": "8080"}))
+	require.NoError(t, err)
+	require.Equal(t, &valueTestConfig{Port: 8080}, v.Load())
+	require.Equal(t, &valueTestConfig{Port: 8080}, <-v.Changes())
+
+	err = v.Reload(ctx, MapSource(map[string]string{"APP_PORT": "0"}))
+	require.ErrorIs(t, err, errInvalidPort)
+	require.Equal(t, &valueTestConfig{Port: 8080}, v.Load())
+
+	err = v.Reload(ctx, FileSource("not-existing.env"))
+	require.ErrorIs(t, err, ErrMissingSource)
+	require.Equal(t, &valueTestConfig{Port: 8080}, v.Load())
+
+	require.NoError(t, v.Reload(ctx, MapSource(map[string]string{"APP_PORT": "1"})))
+	require.NoError(t, v.Reload(ctx, MapSource(map[string]string{"APP_PORT": "2"})))
+
+	// only the latest snapshot is kept in channel
+	require.Equal(t, &valueTestConfig{Port: 2}, <-v.Changes())
+	select {
+	case c := <-v.Changes():
+		t.Fatalf("unexpected change %v", c)
+	default:
+	}
+}
+
+// TestValueConcurrentReload must be run with -race.
+// Readers must never see half-populated snapshot
+func TestValueConcurrentReload(t *testing.T) {
+	t.Parallel()
+
+	v := NewValue[valueTestConfig]()
+	require.NoError(t, v.Set(ParseToNodes([]byte("PORT=0\nPORT-STR=0"))))
+
+	const iterations = 500
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				c := v.Load()
+				if strconv.Itoa(c.Port) != c.PortStr {
+					t.Errorf("half-populated snapshot: %+v", c)
+					return
+				}
+			}
+		}()
+	}
+
+	for i := range iterations {
+		port := strconv.Itoa(i)
+		err := v.Set(ParseToNodes([]byte("PORT=" + port + "\nPORT-STR=" + port)))
+		require.NoError(t, err)
+	}
+
+	close(stop)
+	wg.Wait()
+
+	require.Equal(t, iterations-1, v.Load().Port)
+}
+
+func TestValueSetKeepsNodes(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Ports []struct {
+			Port int `env:"PORT"`
+		} `env:"PORTS"`
+	}
+
+	ns := ParseToNodes([]byte("PORTS_[0]_PORT=80\nPORTS_[1]_PORT=443"))
+	expected := ns[""].Clone()
+
+	v := NewValue[config]()
+	require.NoError(t, v.Set(ns))
+	require.NoError(t, v.Set(ns))
+	require.Len(t, v.Load().Ports, 2)
+	require.Equal(t, 443, v.Load().Ports[1].Port)
+	require.Equal(t, expected, ns[""])
+}
+
+func TestValueOutdatedUpdate(t *testing.T) {
+	t.Parallel()
+
+	v := NewValue[valueTestConfig]()
+
+	older := v.generation.Add(1)
+	newer := v.generation.Add(1)
+
+	require.NoError(t, v.update(newer, "", ParseToNodes([]byte("PORT=2")), nil))
+	require.NoError(t, v.update(older, "", ParseToNodes([]byte("PORT=1")), nil))
+
+	require.Equal(t, 2, v.Load().Port)
+}
@@ -0,0 +1,267 @@
+package evon
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultPollInterval = time.Second
+)
+
+// Watcher polls files of loader and reloads them on change.
+// After every reload subscribers are notified with difference
+// between previous and current nodes
+// e.g.
+//
+//	w, err := NewWatcher(ctx, NewLoader(WithPrefix("APP"), WithProfile(".", "dev")))
+//	w.Subscribe("APP_SERVERS", func(d NodeDiff) { restartServers() })
+//	go w.Run(ctx)
+type Watcher struct {
+	loader       *Loader
+	files        []string
+	pollInterval time.Duration
+	onError      func(error)
+
+	current atomic.Pointer[Node]
+	stamps  map[string]fileStamp
+
+	mu            sync.Mutex
+	reloadMu      sync.Mutex
+	subscriptions map[int]subscription
+	nextSubID     int
+}
+
+type WatcherOpt func(w *Watcher)
+
+// WithPollInterval sets how often files are checked. Default is 1 second
+func WithPollInterval(d time.Duration) WatcherOpt {
+	return func(w *Watcher) {
+		w.pollInterval = d
+	}
+}
+
+// WithWatchFiles adds files to be watched in addition to file sources of loader
+func WithWatchFiles(paths ...string) WatcherOpt {
+	return func(w *Watcher) {
+		w.files = append(w.files, paths...)
+	}
+}
+
+// WithErrorHandler sets handler for reload errors occurred in Run.
+// On error previous nodes are kept
+func WithErrorHandler(f func(error)) WatcherOpt {
+	return func(w *Watcher) {
+		w.onError = f
+	}
+}
+
+type subscription struct {
+	prefix string
+	f      func(d NodeDiff)
+}
+
+type fileStamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// NewWatcher loads nodes with loader and starts tracking its file sources
+func NewWatcher(ctx context.Context, l *Loader, opts ...WatcherOpt) (*Watcher, error) {
+	w := &Watcher{
+		loader:        l,
+		pollInterval:  defaultPollInterval,
+		onError:       func(error) {},
+		stamps:        map[string]fileStamp{},
+		subscriptions: map[int]subscription{},
+	}
+
+	for _, s := range l.sources {
+		path, ok := sourceFile(s)
+		if ok {
+			w.files = append(w.files, path)
+		}
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	w.stamps = w.readStamps()
+
+	ls, err := l.LoadNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	w.current.Store(ls.Storage[""])
+
+	return w, nil
+}
+
+// Nodes returns current nodes. Returned tree must not be modified
+func (w *Watcher) Nodes() *Node {
+	return w.current.Load()
+}
+
+// Subscribe registers function that is called after reload
+// if nodes under prefix were changed. Empty prefix subscribes to every change.
+// Returns function to cancel subscription
+func (w *Watcher) Subscribe(prefix string, f func(d NodeDiff)) (unsubscribe func()) {
+	w.mu.Lock()
+	id := w.nextSubID
+	w.nextSubID++
+	w.subscriptions[id] = subscription{
+		prefix: prefix,
+		f:      f,
+	}
+	w.mu.Unlock()
+
+	return func() {
+		w.mu.Lock()
+		delete(w.subscriptions, id)
+		w.mu.Unlock()
+	}
+}
+
+// Run checks files every poll interval until context is done
+func (w *Watcher) Run(ctx context.Context) error {
+	t := time.NewTicker(w.pollInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+			err := w.Check(ctx)
+			if err != nil {
+				w.onError(err)
+			}
+		}
+	}
+}
+
+// Check reloads nodes if any of watched files was changed
+func (w *Watcher) Check(ctx context.Context) error {
+	w.reloadMu.Lock()
+	stamps := w.readStamps()
+	changed := false
+	for path, s := range stamps {
+		if w.stamps[path] != s {
+			changed = true
+			break
+		}
+	}
+	w.reloadMu.Unlock()
+
+	if !changed {
+		return nil
+	}
+
+	return w.Reload(ctx)
+}
+
+// Reload loads nodes, replaces current ones and notifies subscribers
+func (w *Watcher) Reload(ctx context.Context) error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+
+	stamps := w.readStamps()
+
+	ls, err := w.loader.LoadNodes(ctx)
+	if err != nil {
+		return fmt.Errorf("error reloading nodes: %w", err)
+	}
+
+	w.stamps = stamps
+
+	newNodes := ls.Storage[""]
+	oldNodes := w.current.Swap(newNodes)
+
+	d := Diff(oldNodes, newNodes)
+	if d.IsEmpty() {
+		return nil
+	}
+
+	w.mu.Lock()
+	subs := make([]subscription, 0, len(w.subscriptions))
+	for _, s := range w.subscriptions {
+		subs = append(subs, s)
+	}
+	w.mu.Unlock()
+
+	for _, s := range subs {
+		sd := d.WithPrefix(s.prefix)
+		if !sd.IsEmpty() {
+			s.f(sd)
+		}
+	}
+
+	return nil
+}
+
+func (w *Watcher) readStamps() map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(w.files))
+	for _, path := range w.files {
+		info, err := os.Stat(path)
+		if err != nil {
+			stamps[path] = fileStamp{}
+			continue
+		}
+
+		stamps[path] = fileStamp{
+			exists:  true,
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		}
+	}
+
+	return stamps
+}
+
+// BindValue publishes current nodes of watcher into v
+// and publishes new snapshot after every reload.
+// Loader's prefix and unmarshal options are used.
+// Snapshots that fail to unmarshal or validate are reported to error handler.
+// Returns function that stops updating v
+func BindValue[T any](w *Watcher, v *Value[T]) (unbind func(), err error) {
+	update := func() error {
+		gen := v.generation.Add(1)
+		ns := NodesToStorage(w.Nodes())
+
+		opts := slices.Concat(w.loader.unmarshalOpts, v.opts.unmarshalOpts)
+		return v.update(gen, w.loader.prefix, ns, opts)
+	}
+
+	err = update()
+	if err != nil {
+		return nil, err
+	}
+
+	unbind = w.Subscribe(w.loader.prefix, func(_ NodeDiff) {
+		err := update()
+		if err != nil {
+			w.onError(err)
+		}
+	})
+
+	return unbind, nil
+}
+
+func sourceFile(s Source) (string, bool) {
+	switch s := s.(type) {
+	case *fileSource:
+		return s.path, true
+	case *optionalSource:
+		return sourceFile(s.Source)
+	default:
+		return "", false
+	}
+}
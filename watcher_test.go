@@ -0,0 +1,165 @@
+package evon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), ".env")
+	writeTestFile(t, path, `APP_SERVERS_REST_PORT=8080
+APP_DB_HOST=localhost`)
+
+	w, err := NewWatcher(ctx, NewLoader(WithPrefix("APP"), WithFile(path)))
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var serversDiffs, dbDiffs []NodeDiff
+
+	w.Subscribe("APP_SERVERS", func(d NodeDiff) {
+		mu.Lock()
+		serversDiffs = append(serversDiffs, d)
+		mu.Unlock()
+	})
+	unsubscribe := w.Subscribe("APP_DB", func(d NodeDiff) {
+		mu.Lock()
+		dbDiffs = append(dbDiffs, d)
+		mu.Unlock()
+	})
+
+	type config struct {
+		Servers struct {
+			Rest struct {
+				Port uint16 `env:"PORT"`
+			} `env:"REST"`
+		} `env:"SERVERS"`
+	}
+
+	var v Value[config]
+	unbind, err := BindValue(w, &v)
+	require.NoError(t, err)
+	defer unbind()
+	require.Equal(t, uint16(8080), v.Load().Servers.Rest.Port)
+
+	require.NoError(t, w.Check(ctx))
+	require.Empty(t, serversDiffs)
+
+	writeTestFile(t, path, `APP_SERVERS_REST_PORT=9090
+APP_DB_HOST=localhost`)
+	touch(t, path)
+
+	require.NoError(t, w.Check(ctx))
+
+	require.Equal(t, []NodeDiff{
+		{
+			Changed: []NodeChange{
+				{Path: "APP_SERVERS_REST_PORT", Old: "8080", New: "9090"},
+			},
+		},
+	}, serversDiffs)
+	require.Empty(t, dbDiffs)
+	require.Equal(t, uint16(9090), v.Load().Servers.Rest.Port)
+
+	unsubscribe()
+	writeTestFile(t, path, `APP_SERVERS_REST_PORT=9090`)
+	touch(t, path)
+
+	require.NoError(t, w.Check(ctx))
+	require.Empty(t, dbDiffs)
+	require.Len(t, serversDiffs, 1)
+}
+
+func TestWatcherRun(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := filepath.Join(t.TempDir(), ".env")
+	writeTestFile(t, path, `PORT=8080`)
+
+	w, err := NewWatcher(ctx,
+		NewLoader(WithFile(path)),
+		WithPollInterval(time.Millisecond))
+	require.NoError(t, err)
+
+	changes := make(chan NodeDiff, 1)
+	w.Subscribe("", func(d NodeDiff) {
+		changes <- d
+	})
+
+	done := make(chan error)
+	go func() {
+		done <- w.Run(ctx)
+	}()
+
+	writeTestFile(t, path, `PORT=9090`)
+	touch(t, path)
+
+	select {
+	case d := <-changes:
+		require.Equal(t, []NodeChange{{Path: "PORT", Old: "8080", New: "9090"}}, d.Changed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change wasn't detected")
+	}
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+}
+
+func TestBindValueKeepsWatcherNodes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), ".env")
+	writeTestFile(t, path, `APP_HOSTS_[0]_NAME=a
+APP_HOSTS_[1]_NAME=b`)
+
+	w, err := NewWatcher(ctx, NewLoader(WithPrefix("APP"), WithFile(path)))
+	require.NoError(t, err)
+
+	type config struct {
+		Hosts []struct {
+			Name string `env:"NAME"`
+		} `env:"HOSTS"`
+	}
+
+	before := string(Marshal(w.Nodes().InnerNodes))
+
+	var v Value[config]
+	unbind, err := BindValue(w, &v)
+	require.NoError(t, err)
+	require.Len(t, v.Load().Hosts, 2)
+
+	require.Equal(t, before, string(Marshal(w.Nodes().InnerNodes)))
+
+	unbind()
+	writeTestFile(t, path, `APP_HOSTS_[0]_NAME=c`)
+	touch(t, path)
+
+	require.NoError(t, w.Check(ctx))
+	require.Len(t, v.Load().Hosts, 2)
+}
+
+// touch moves modification time forward
+// in order not to depend on file system's time resolution
+func touch(t *testing.T, path string) {
+	t.Helper()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+
+	mt := info.ModTime().Add(time.Second)
+	require.NoError(t, os.Chtimes(path, mt, mt))
+}
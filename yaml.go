@@ -0,0 +1,276 @@
+package evon
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	yamlMergeTag = "!!merge"
+	yamlNullTag  = "!!null"
+	yamlIndent   = 2
+)
+
+// FromYAML converts yaml document into tree of nodes.
+// Mapping keys are upper-cased and "_" in them is replaced with FieldSplitter,
+// sequence elements are stored as "_[i]" nodes and scalars are kept as strings.
+// Keys order of document is preserved
+// e.g.
+//
+//	ingester:
+//	  wal_dir: /loki/wal
+//	  peers: [a, b]
+//
+// is converted into
+//
+//	INGESTER_WAL-DIR=/loki/wal
+//	INGESTER_PEERS_[0]=a
+//	INGESTER_PEERS_[1]=b
+func FromYAML(data []byte) (*Node, error) {
+	doc := &yaml.Node{}
+	err := yaml.Unmarshal(data, doc)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing yaml: %w", err)
+	}
+
+	root := &Node{}
+	if len(doc.Content) == 0 {
+		return root, nil
+	}
+
+	err = fromYAMLNode(root, doc.Content[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+func fromYAMLNode(n *Node, yn *yaml.Node) error {
+	switch yn.Kind {
+	case yaml.AliasNode:
+		return fromYAMLNode(n, yn.Alias)
+
+	case yaml.ScalarNode:
+		if yn.Tag != yamlNullTag {
+			n.Value = yn.Value
+		}
+
+	case yaml.SequenceNode:
+		for idx, elem := range yn.Content {
+			inner := &Node{
+				Name: joinPath(n.Name, fmt.Sprintf("[%d]", idx)),
+			}
+
+			err := fromYAMLNode(inner, elem)
+			if err != nil {
+				return err
+			}
+
+			n.InnerNodes = append(n.InnerNodes, inner)
+		}
+
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(yn.Content); i += 2 {
+			key, value := yn.Content[i], yn.Content[i+1]
+
+			if key.Tag == yamlMergeTag {
+				err := fromYAMLNode(n, value)
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if key.Kind != yaml.ScalarNode {
+				return fmt.Errorf("unsupported yaml key at line %d of %s: only scalar keys are supported", key.Line, n.Name)
+			}
+
+			name := joinPath(n.Name, strings.ToUpper(nameToEvonName(key.Value)))
+
+			inner := findInnerNode(n, name)
+			if inner == nil {
+				inner = &Node{Name: name}
+				n.InnerNodes = append(n.InnerNodes, inner)
+			}
+
+			err := fromYAMLNode(inner, value)
+			if err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("unsupported yaml node kind %d at line %d", yn.Kind, yn.Line)
+	}
+
+	return nil
+}
+
+// ToYAML converts tree of nodes into yaml document.
+// Names are lower-cased and FieldSplitter in them is replaced with "_",
+// nodes with "_[i]" inner nodes become sequences.
+// Values are converted to yaml types same way as in unmarshalling into map
+// e.g. "true" becomes bool, "3100" becomes int.
+// Values that would change on round trip stay strings e.g. "3.10" or "0755"
+func ToYAML(n *Node) ([]byte, error) {
+	yn, err := toYAMLNode(n)
+	if err != nil {
+		return nil, err
+	}
+
+	return encodeYAML(yn)
+}
+
+func encodeYAML(yn *yaml.Node) ([]byte, error) {
+	b := &bytes.Buffer{}
+	enc := yaml.NewEncoder(b)
+	enc.SetIndent(yamlIndent)
+
+	err := enc.Encode(yn)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding yaml: %w", err)
+	}
+
+	err = enc.Close()
+	if err != nil {
+		return nil, fmt.Errorf("error encoding yaml: %w", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+func toYAMLNode(n *Node) (*yaml.Node, error) {
+	if len(n.InnerNodes) == 0 {
+		if s, ok := n.Value.(string); ok {
+			return yamlScalar(s), nil
+		}
+
+		yn := &yaml.Node{}
+		err := yn.Encode(n.Value)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding value of %s: %w", n.Name, err)
+		}
+
+		return yn, nil
+	}
+
+	if n.Value != nil {
+		return nil, fmt.Errorf("node %s has both value and inner nodes and can't be converted to yaml", n.Name)
+	}
+
+	inner, isSequence := sliceElements(n)
+	if isSequence {
+		yn := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, elem := range inner {
+			elemNode, err := toYAMLNode(elem)
+			if err != nil {
+				return nil, err
+			}
+
+			yn.Content = append(yn.Content, elemNode)
+		}
+
+		return yn, nil
+	}
+
+	yn := &yaml.Node{Kind: yaml.MappingNode}
+	for _, child := range n.InnerNodes {
+		key := &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Value: fieldNameToKey(relativeChildName(n.Name, child.Name)),
+		}
+
+		value, err := toYAMLNode(child)
+		if err != nil {
+			return nil, err
+		}
+
+		yn.Content = append(yn.Content, key, value)
+	}
+
+	return yn, nil
+}
+
+// yamlScalar returns scalar typed same way as in unmarshalling into map.
+// Value is typed only if it's formatted back into the same string
+// e.g. "3100" becomes int, but "3.10", "0755" and "+1" stay strings
+func yamlScalar(s string) *yaml.Node {
+	tag := "!!str"
+
+	switch typed := (mapValueMapper{}).mapWithType(s).(type) {
+	case bool:
+		if strconv.FormatBool(typed) == s {
+			tag = "!!bool"
+		}
+	case int:
+		if strconv.Itoa(typed) == s {
+			tag = "!!int"
+		}
+	case float64:
+		if strconv.FormatFloat(typed, 'f', -1, 64) == s {
+			tag = "!!float"
+		}
+	}
+
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: s}
+}
+
+// sliceElements returns inner nodes sorted by index
+// if all of them are slice elements "_[i]"
+func sliceElements(n *Node) ([]*Node, bool) {
+	indexes := make(map[*Node]int, len(n.InnerNodes))
+	for _, inner := range n.InnerNodes {
+		idx, ok := parseSliceIndex(relativeChildName(n.Name, inner.Name))
+		if !ok {
+			return nil, false
+		}
+
+		indexes[inner] = idx
+	}
+
+	out := append([]*Node(nil), n.InnerNodes...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return indexes[out[i]] < indexes[out[j]]
+	})
+
+	return out, true
+}
+
+// relativeChildName trims parent's name from child's name
+func relativeChildName(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+
+	return strings.TrimPrefix(name, parent+ObjectSplitter)
+}
+
+// fieldNameToKey converts env name into snake cased key
+// e.g. "WAL-DIR" -> "wal_dir".
+// It's shared by yaml, json, toml and ini codecs,
+// so keys of all of them are read back into the same names
+func fieldNameToKey(name string) string {
+	return lowerName(strings.ReplaceAll(name, FieldSplitter, "_"))
+}
+
+// lowerName lower-cases name so that it is upper-cased back into the same name.
+// Letters without such lower-case pair are kept as is
+// e.g. "ϴ" is kept since upper-cased "θ" is "Θ"
+func lowerName(name string) string {
+	return strings.Map(func(r rune) rune {
+		lower := unicode.ToLower(r)
+		if unicode.ToUpper(lower) != r {
+			return r
+		}
+
+		return lower
+	}, name)
+}
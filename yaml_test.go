@@ -0,0 +1,216 @@
+package evon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FromYAML(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		input    string
+		expected string
+	}
+
+	tests := map[string]testCase{
+		"EMPTY": {
+			input:    "",
+			expected: "",
+		},
+		"NESTED": {
+			input: `
+ingester:
+  wal_dir: /loki/wal
+  replication_factor: 1
+server:
+  http_listen_port: 3100
+`,
+			expected: `INGESTER_WAL-DIR=/loki/wal
+INGESTER_REPLICATION-FACTOR=1
+SERVER_HTTP-LISTEN-PORT=3100
+`,
+		},
+		"SEQUENCES": {
+			input: `
+peers: [a, b]
+configs:
+  - from: "2020-10-24"
+    index:
+      period: 24h
+  - from: "2021-01-01"
+`,
+			expected: `PEERS_[0]=a
+PEERS_[1]=b
+CONFIGS_[0]_FROM=2020-10-24
+CONFIGS_[0]_INDEX_PERIOD=24h
+CONFIGS_[1]_FROM=2021-01-01
+`,
+		},
+		"ALIASES": {
+			input: `
+defaults: &defaults
+  host: localhost
+  port: 5432
+postgres:
+  <<: *defaults
+  port: 5433
+replica: *defaults
+`,
+			expected: `DEFAULTS_HOST=localhost
+DEFAULTS_PORT=5432
+POSTGRES_HOST=localhost
+POSTGRES_PORT=5433
+REPLICA_HOST=localhost
+REPLICA_PORT=5432
+`,
+		},
+		"NULL": {
+			input: `
+a: ~
+b: ""
+`,
+			expected: `B=
+`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			root, err := FromYAML([]byte(tc.input))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(Marshal(root.InnerNodes)))
+		})
+	}
+}
+
+func Test_FromYAMLError(t *testing.T) {
+	t.Parallel()
+
+	_, err := FromYAML([]byte("a: [b"))
+	require.ErrorContains(t, err, "error parsing yaml")
+
+	_, err = FromYAML([]byte("? [a, b]\n: c\n"))
+	require.ErrorContains(t, err, "only scalar keys are supported")
+}
+
+func Test_ToYAML(t *testing.T) {
+	t.Parallel()
+
+	ns := ParseToNodes([]byte(`
+AUTH-ENABLED=false
+SERVER_HTTP-LISTEN-PORT=3100
+SERVER_NAME=loki
+LIMITS_RATIO=0.5
+PEERS_[1]=b
+PEERS_[0]=a
+`))
+
+	actual, err := ToYAML(ns[""])
+	require.NoError(t, err)
+	require.Equal(t, `auth_enabled: false
+server:
+  http_listen_port: 3100
+  name: loki
+limits:
+  ratio: 0.5
+peers:
+  - a
+  - b
+`, string(actual))
+
+	ns = ParseToNodes([]byte(`
+VERSION=3.10
+MODE=0755
+OFFSET=+1
+NEGATIVE=-0
+FLOAT=1.0
+EXP=1.5e3
+FLAG=True
+PORT=-80
+`))
+
+	actual, err = ToYAML(ns[""])
+	require.NoError(t, err)
+	require.Equal(t, `version: "3.10"
+mode: "0755"
+offset: "+1"
+negative: "-0"
+float: "1.0"
+exp: "1.5e3"
+flag: "True"
+port: -80
+`, string(actual))
+
+	root, err := FromYAML(actual)
+	require.NoError(t, err)
+	require.Equal(t, string(Marshal(ns[""].InnerNodes)), string(Marshal(root.InnerNodes)))
+}
+
+func Test_ToYAMLKeys(t *testing.T) {
+	t.Parallel()
+
+	// "ϴ" and Kelvin sign "K" have no lower-case pair:
+	// their lower-cased letters are upper-cased into "Θ" and "K"
+	ns := ParseToNodes([]byte("ÉTÉ_ϴ=1\nÉTÉ_\u212a=2\n"))
+
+	actual, err := ToYAML(ns[""])
+	require.NoError(t, err)
+	require.Equal(t, "été:\n  ϴ: 1\n  \u212a: 2\n", string(actual))
+
+	root, err := FromYAML(actual)
+	require.NoError(t, err)
+	require.Equal(t, string(Marshal(ns[""].InnerNodes)), string(Marshal(root.InnerNodes)))
+}
+
+func Test_YAMLRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	root, err := FromYAML(complexYamlConfig)
+	require.NoError(t, err)
+
+	actual, err := ToYAML(root)
+	require.NoError(t, err)
+	require.YAMLEq(t, string(complexYamlConfig), string(actual))
+
+	// through env file
+	ns := ParseToNodes(Marshal(root.InnerNodes))
+
+	actual, err = ToYAML(ns[""])
+	require.NoError(t, err)
+	require.YAMLEq(t, string(complexYamlConfig), string(actual))
+}
+
+func Test_YAMLOverrideWithEnv(t *testing.T) {
+	t.Parallel()
+
+	root, err := FromYAML([]byte(`
+ingester:
+  wal:
+    dir: /loki/wal
+    enabled: true
+server:
+  http_listen_port: 3100
+`))
+	require.NoError(t, err)
+
+	ns := NodesToStorage(root)
+	UniteStorages(ParseToNodes([]byte(`
+INGESTER_WAL_DIR=/data/wal
+SERVER_GRPC-LISTEN-PORT=9095
+`)), ns)
+
+	actual, err := ToYAML(ns[""])
+	require.NoError(t, err)
+	require.Equal(t, `ingester:
+  wal:
+    dir: /data/wal
+    enabled: true
+server:
+  http_listen_port: 3100
+  grpc_listen_port: 9095
+`, string(actual))
+}